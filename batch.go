@@ -0,0 +1,109 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrClientClosed is returned when work is started on a closed client.
+var ErrClientClosed = errors.New("httpx: client is closed")
+
+// BatchRequest describes a single sub-request of a batch.
+type BatchRequest struct {
+	Method  string   // HTTP method, e.g. http.MethodGet
+	URL     string   // Request URL
+	Options []Option // Per-request options
+}
+
+// BatchResult is the outcome of a single sub-request of a batch.
+type BatchResult struct {
+	Response *http.Response // Response on success, nil on error
+	Err      error          // Error of the sub-request, nil on success
+}
+
+// BatchCall is a running batch of concurrent requests started by Batch.
+//
+// Every sub-request runs under its own context derived from the parent
+// context passed to Batch, so it inherits the parent's deadline and
+// cancellation but can also be cancelled individually via Cancel.
+type BatchCall struct {
+	cancels []context.CancelFunc
+	results []BatchResult
+	wg      sync.WaitGroup
+}
+
+// Batch starts all requests concurrently and returns immediately. Cancelling
+// ctx aborts every sub-request still in flight; Cancel aborts a single one.
+//
+// A sub-request context is released when its response body is closed or when
+// the sub-request fails, so callers must close every returned body as usual.
+// Per-request WithContext options are overridden by the batch context.
+//
+// Example:
+//
+//	call := client.Batch(ctx,
+//	    httpx.BatchRequest{Method: http.MethodGet, URL: "https://api.com/a"},
+//	    httpx.BatchRequest{Method: http.MethodGet, URL: "https://api.com/b"},
+//	)
+//	call.Cancel(1) // give up on b only
+//	for _, r := range call.Wait() { ... }
+func (c *client) Batch(ctx context.Context, reqs ...BatchRequest) *BatchCall {
+	call := &BatchCall{
+		cancels: make([]context.CancelFunc, len(reqs)),
+		results: make([]BatchResult, len(reqs)),
+	}
+
+	for i, r := range reqs {
+		subCtx, cancel := context.WithCancel(ctx)
+		call.cancels[i] = cancel
+
+		call.wg.Add(1)
+		i, r := i, r
+
+		started := c.tasks.spawn(fmt.Sprintf("batch %s %s", r.Method, r.URL), func(taskCtx context.Context) {
+			defer call.wg.Done()
+
+			// Closing the client aborts requests still in flight
+			stop := context.AfterFunc(taskCtx, cancel)
+			defer stop()
+
+			opts := append(append([]Option{}, r.Options...), WithContext(subCtx))
+			res, err := c.Do(r.Method, r.URL, opts...)
+			if err != nil {
+				cancel()
+				call.results[i] = BatchResult{Err: err}
+				return
+			}
+
+			// Release the derived context together with the body
+			res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+			call.results[i] = BatchResult{Response: res}
+		})
+
+		if !started {
+			cancel()
+			call.results[i] = BatchResult{Err: ErrClientClosed}
+			call.wg.Done()
+		}
+	}
+
+	return call
+}
+
+// Cancel aborts the sub-request at index i without affecting the others.
+// Cancelling a finished sub-request invalidates its response body.
+func (b *BatchCall) Cancel(i int) {
+	if i >= 0 && i < len(b.cancels) {
+		b.cancels[i]()
+	}
+}
+
+// Wait blocks until every sub-request has finished and returns the results
+// in the order the requests were passed to Batch.
+func (b *BatchCall) Wait() []BatchResult {
+	b.wg.Wait()
+	return b.results
+}
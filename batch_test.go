@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// blockingServer answers /fast at once and holds every other request until
+// the client gives up.
+func blockingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fast" {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBatchCancel(t *testing.T) {
+	srv := blockingServer(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	call := c.Batch(ctx,
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/slow"},
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/fast"},
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/slow"},
+	)
+
+	// Cancelling one sub-request leaves the others running
+	call.Cancel(0)
+
+	// The parent context aborts the rest
+	cancel()
+
+	results := call.Wait()
+	for _, i := range []int{0, 2} {
+		if !errors.Is(results[i].Err, context.Canceled) {
+			t.Errorf("result %d: err = %v, want context.Canceled", i, results[i].Err)
+		}
+	}
+
+	if results[1].Err != nil {
+		// The fast request may lose the race against the parent cancel
+		if !errors.Is(results[1].Err, context.Canceled) {
+			t.Errorf("result 1: err = %v", results[1].Err)
+		}
+		return
+	}
+	if _, err := readBodyWithStatus(results[1].Response); err != nil {
+		t.Errorf("result 1: %v", err)
+	}
+}
+
+func TestBatchCancelOne(t *testing.T) {
+	srv := blockingServer(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	call := c.Batch(context.Background(),
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/slow"},
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/fast"},
+	)
+	call.Cancel(0)
+
+	results := call.Wait()
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("cancelled request: err = %v", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Fatalf("other request: err = %v", results[1].Err)
+	}
+	if _, err := readBodyWithStatus(results[1].Response); err != nil {
+		t.Errorf("other request: %v", err)
+	}
+}
+
+func TestBatchValidatesMethods(t *testing.T) {
+	srv := blockingServer(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	results := c.Batch(context.Background(),
+		BatchRequest{Method: "BAD METHOD", URL: srv.URL + "/fast"},
+		BatchRequest{Method: "", URL: srv.URL + "/fast"},
+		BatchRequest{Method: http.MethodGet, URL: srv.URL + "/fast"},
+	).Wait()
+
+	for i := range 2 {
+		if results[i].Err == nil {
+			results[i].Response.Body.Close()
+			t.Errorf("result %d: invalid method accepted", i)
+		}
+	}
+	if results[2].Err != nil {
+		t.Fatal(results[2].Err)
+	}
+	results[2].Response.Body.Close()
+}
+
+func TestBatchOnClosedClient(t *testing.T) {
+	c := New(nil)
+	c.Close(context.Background())
+
+	results := c.Batch(context.Background(), BatchRequest{Method: http.MethodGet, URL: "http://example.com"}).Wait()
+	if !errors.Is(results[0].Err, ErrClientClosed) {
+		t.Errorf("err = %v, want ErrClientClosed", results[0].Err)
+	}
+}
@@ -0,0 +1,318 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBodyEncodingMatrix pins what each body type produces on the wire for
+// every Content-Type, as documented in the README.
+func TestBodyEncodingMatrix(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	type P struct {
+		A string `json:"a" xml:"a" url:"a"`
+	}
+	bodies := map[string]func() any{
+		"string":     func() any { return "a=1" },
+		"bytes":      func() any { return []byte("a=1") },
+		"reader":     func() any { return strings.NewReader("a=1") },
+		"url.Values": func() any { return url.Values{"a": {"1"}} },
+		"map":        func() any { return map[string]string{"a": "1"} },
+		"struct":     func() any { return P{A: "1"} },
+	}
+
+	const (
+		textCT  = "text/plain; charset=utf-8"
+		octetCT = "application/octet-stream"
+		formCT  = "application/x-www-form-urlencoded"
+		jsonCT  = "application/json"
+		xmlCT   = "application/xml"
+		failed  = "error"
+	)
+	// want maps a body to its wire body, or failed
+	tests := []struct {
+		defaultType string
+		contentType string
+		wireType    string // "" means inferred, given as "type|body" in want
+		want        map[string]string
+	}{
+		{"", "", "", map[string]string{
+			"string": textCT + "|a=1", "bytes": octetCT + "|a=1", "reader": octetCT + "|a=1",
+			"url.Values": formCT + "|a=1", "map": jsonCT + `|{"a":"1"}`, "struct": jsonCT + `|{"a":"1"}`,
+		}},
+		{"", jsonCT, jsonCT, map[string]string{
+			"string": `"a=1"`, "bytes": `"YT0x"`, "reader": `{}`,
+			"url.Values": `{"a":["1"]}`, "map": `{"a":"1"}`, "struct": `{"a":"1"}`,
+		}},
+		{"", "text/plain", "text/plain", map[string]string{
+			"string": "a=1", "bytes": "a=1", "reader": "a=1",
+			"url.Values": "map[a:[1]]", "map": "map[a:1]", "struct": "{1}",
+		}},
+		{"", octetCT, octetCT, map[string]string{
+			"string": failed, "bytes": "a=1", "reader": "a=1",
+			"url.Values": failed, "map": failed, "struct": failed,
+		}},
+		{"", formCT, formCT, map[string]string{
+			"string": failed, "bytes": failed, "reader": "",
+			"url.Values": "a=1", "map": "a=1", "struct": "a=1",
+		}},
+		{"", xmlCT, xmlCT, map[string]string{
+			"string": "<string>a=1</string>", "bytes": failed, "reader": "<Reader></Reader>",
+			"url.Values": failed, "map": failed, "struct": "<P><a>1</a></P>",
+		}},
+		// The earlier behavior: everything is JSON unless a type is set
+		{jsonCT, "", jsonCT, map[string]string{
+			"string": `"a=1"`, "bytes": `"YT0x"`, "reader": `{}`,
+			"url.Values": `{"a":["1"]}`, "map": `{"a":"1"}`, "struct": `{"a":"1"}`,
+		}},
+		{jsonCT, "text/plain", "text/plain", map[string]string{
+			"string": "a=1", "bytes": "a=1", "reader": "a=1",
+			"url.Values": "map[a:[1]]", "map": "map[a:1]", "struct": "{1}",
+		}},
+	}
+	for _, tt := range tests {
+		c := New(&Config{DefaultContentType: tt.defaultType})
+		defer c.Close(context.Background())
+
+		for name, body := range bodies {
+			t.Run(tt.defaultType+"/"+tt.contentType+"/"+name, func(t *testing.T) {
+				opts := []Option{WithBody(body())}
+				if tt.contentType != "" {
+					opts = append(opts, WithHeaders(http.Header{"Content-Type": {tt.contentType}}))
+				}
+
+				res, err := c.Post(srv.URL, opts...)
+				want := tt.want[name]
+				if want == failed {
+					if err == nil {
+						res.Body.Close()
+						t.Fatal("body was sent")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := readBodyWithStatus(res)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				wireType := tt.wireType
+				if wireType == "" {
+					wireType, want, _ = strings.Cut(want, "|")
+				}
+				if ct := res.Header.Get("X-Content-Type"); ct != wireType {
+					t.Errorf("Content-Type = %q, want %q", ct, wireType)
+				}
+				if string(got) != want {
+					t.Errorf("body = %q, want %q", got, want)
+				}
+			})
+		}
+	}
+}
+
+// expensiveBody records whether anything tried to encode it.
+type expensiveBody struct{ encoded *atomic.Bool }
+
+func (b expensiveBody) MarshalJSON() ([]byte, error) {
+	b.encoded.Store(true)
+	return bytes.Repeat([]byte(" "), 256<<20), nil
+}
+
+// endlessReader counts its reads and never ends.
+type endlessReader struct{ reads atomic.Int64 }
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	return len(p), nil
+}
+
+func TestBodyEncodingCancelledContext(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var encoded atomic.Bool
+	file := &endlessReader{}
+	tests := map[string]Option{
+		"JSON":      WithBody(expensiveBody{&encoded}),
+		"multipart": WithMultipart(nil, MultipartFile{Name: "f", Filename: "f.bin", Reader: file}),
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			started := time.Now()
+			_, err := c.Post(srv.URL, body, WithContext(ctx))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("returned after %v", elapsed)
+			}
+		})
+	}
+
+	// Nothing was encoded or sent
+	if encoded.Load() || file.reads.Load() > 0 || hits.Load() > 0 {
+		t.Errorf("encoded %v, file reads %d, server hits %d", encoded.Load(), file.reads.Load(), hits.Load())
+	}
+}
+
+func TestMultipartEncoderStopsOnCancel(t *testing.T) {
+	receiving := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body.Read(make([]byte, 1))
+		close(receiving)
+		io.Copy(io.Discard, r.Body) // until the client gives up
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	file := &endlessReader{}
+	go func() {
+		<-receiving
+		cancel()
+	}()
+
+	_, err := c.Post(srv.URL, WithContext(ctx),
+		WithMultipart(nil, MultipartFile{Name: "f", Filename: "f.bin", Reader: file}))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+
+	// The encoder goroutine stops reading the file
+	deadline := time.Now().Add(time.Second)
+	for {
+		before := file.reads.Load()
+		time.Sleep(20 * time.Millisecond)
+		if file.reads.Load() == before {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("encoder still reading after the context was cancelled")
+		}
+	}
+}
+
+func TestBodyEncodingSuffixes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	type Entry struct {
+		XMLName struct{} `json:"-" xml:"entry"`
+		Title   string   `json:"title" xml:"title"`
+	}
+	const (
+		asJSON = `{"title":"Go"}`
+		asXML  = `<entry><title>Go</title></entry>`
+	)
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/ld+json", asJSON},
+		{"application/vnd.api+json", asJSON},
+		{"application/problem+json; charset=utf-8", asJSON},
+		{"Application/LD+JSON", asJSON},
+		{"application/atom+xml", asXML},
+		{"image/svg+xml", asXML},
+		{"application/vnd.feed+xml; charset=utf-8", asXML},
+		{"text/xml", asXML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			res, err := c.Post(srv.URL, WithBody(Entry{Title: "Go"}),
+				WithHeaders(http.Header{"Content-Type": {tt.contentType}}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readBodyWithStatus(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+			if ct := res.Header.Get("X-Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want it unchanged", ct)
+			}
+		})
+	}
+}
+
+func TestCustomJSONCodec(t *testing.T) {
+	srv := flakyServer(t, 0)
+
+	var marshals, unmarshals atomic.Int64
+	c := New(&Config{
+		JSONMarshal: func(v any) ([]byte, error) {
+			marshals.Add(1)
+			return json.MarshalIndent(v, "", "  ")
+		},
+		JSONUnmarshal: func(data []byte, v any) error {
+			unmarshals.Add(1)
+			return json.Unmarshal(data, v)
+		},
+	})
+	defer c.Close(context.Background())
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	res, err := c.Post(srv.URL, WithBody(user{Name: "Ada"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The echoed body shows the injected encoder's output
+	got, err := JSON[user](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := readBodyWithStatus(res)
+	if string(raw) != "{\n  \"name\": \"Ada\"\n}" {
+		t.Errorf("wire body = %q, want the indented encoding", raw)
+	}
+	if got.Name != "Ada" {
+		t.Errorf("decoded %+v", got)
+	}
+	if marshals.Load() != 1 || unmarshals.Load() != 1 {
+		t.Errorf("%d marshals, %d unmarshals; want one each", marshals.Load(), unmarshals.Load())
+	}
+}
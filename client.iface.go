@@ -1,7 +1,10 @@
 package httpx
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"net/url"
 )
 
 // Client defines the public-facing interface for the httpx HTTP client.
@@ -18,8 +21,9 @@ type Client interface {
 
 	// Get performs an HTTP GET request to the given URL.
 	//
-	// GET requests cannot include a request body. Optional behavior such as
-	// query parameters or additional headers can be configured via Option.
+	// GET requests cannot include a request body unless WithBodyAllowed is
+	// set. Optional behavior such as query parameters or additional headers
+	// can be configured via Option.
 	//
 	// Example:
 	//    res, err := client.Get("https://api.com/users",
@@ -28,6 +32,29 @@ type Client interface {
 	//    )
 	Get(url string, opts ...Option) (*http.Response, error)
 
+	// Head performs an HTTP HEAD request to the given URL.
+	//
+	// HEAD requests cannot include a request body. They are useful for cheap
+	// existence checks or for reading Content-Length before a download. The
+	// response helpers treat the empty body as valid: Bytes returns an empty
+	// slice and JSON/XML return the zero value.
+	//
+	// Example:
+	//    res, err := client.Head("https://api.com/files/big.iso")
+	//    fmt.Println(res.ContentLength)
+	Head(url string, opts ...Option) (*http.Response, error)
+
+	// Options performs an HTTP OPTIONS request to the given URL, e.g. to probe
+	// CORS or Allow behaviour. Query parameters and headers are supported,
+	// request bodies are not. Servers often answer with 204 and headers only.
+	//
+	// Example:
+	//    res, err := client.Options("https://api.com/users",
+	//        httpx.WithHeaders(http.Header{"Origin": []string{"https://app.com"}}),
+	//    )
+	//    fmt.Println(httpx.AllowedMethods(res))
+	Options(url string, opts ...Option) (*http.Response, error)
+
 	// Post performs an HTTP POST request using optional headers, query parameters,
 	// and a request body. Body encoding is determined automatically based on the
 	// Content-Type header (JSON, XML, x-www-form-urlencoded, multipart/form-data, etc.).
@@ -58,12 +85,152 @@ type Client interface {
 	Patch(url string, opts ...Option) (*http.Response, error)
 
 	// Delete performs an HTTP DELETE request. It supports optional headers and
-	// query parameters but does not allow request bodies in httpx to avoid
-	// inconsistent behavior across HTTP servers.
+	// query parameters. Request bodies are rejected by default to avoid
+	// inconsistent behavior across HTTP servers; WithBodyAllowed enables them
+	// for APIs such as Elasticsearch's _delete_by_query.
 	//
 	// Example:
 	//    res, err := client.Delete("https://api.com/users/1",
 	//        httpx.WithParams(map[string]string{"force": "true"}),
 	//    )
 	Delete(url string, opts ...Option) (*http.Response, error)
+
+	// Request performs an HTTP request with an arbitrary method. It is
+	// identical to Do.
+	//
+	// Deprecated: use Do.
+	Request(method, url string, opts ...Option) (*http.Response, error)
+
+	// Do performs an HTTP request with any method, e.g. PROPFIND or REPORT for
+	// WebDAV/CalDAV. The method is validated as an HTTP token but not checked
+	// against a whitelist. It shares the header merge, body encoding and
+	// parameter logic with every other method, including the body
+	// restrictions of GET, HEAD, OPTIONS and DELETE.
+	//
+	// Example:
+	//    res, err := client.Do("PROPFIND", "https://dav.com/files/",
+	//        httpx.WithHeaders(http.Header{"Depth": []string{"1"}}),
+	//    )
+	Do(method, url string, opts ...Option) (*http.Response, error)
+
+	// Batch starts the given requests concurrently and returns a handle to
+	// wait for their results. Every sub-request inherits the deadline and
+	// cancellation of ctx and can additionally be cancelled on its own.
+	//
+	// Example:
+	//    call := client.Batch(ctx, reqA, reqB)
+	//    call.Cancel(1)
+	//    results := call.Wait()
+	Batch(ctx context.Context, reqs ...BatchRequest) *BatchCall
+
+	// SetHeaders sets global headers sent with every request, replacing
+	// existing values of the same keys. Together with SetHeader and
+	// RemoveHeader it is safe to call concurrently with in-flight requests,
+	// e.g. to rotate a token from a background goroutine.
+	//
+	// Example:
+	//    client.SetHeaders(http.Header{"Authorization": []string{"Bearer " + token}})
+	SetHeaders(h http.Header)
+
+	// SetHeader sets a single global header, replacing any existing value.
+	//
+	// Example:
+	//    client.SetHeader("Authorization", "Bearer "+token)
+	SetHeader(key, value string)
+
+	// RemoveHeader deletes a global header.
+	//
+	// Example:
+	//    client.RemoveHeader("X-Debug")
+	RemoveHeader(key string)
+
+	// Cookies returns the cookies the client's jar holds for u, or nil when
+	// cookies are disabled (see Config.CookieJar and Config.EnableCookies).
+	//
+	// Example:
+	//    u, _ := url.Parse("https://api.com/")
+	//    session := client.Cookies(u)
+	Cookies(u *url.URL) []*http.Cookie
+
+	// WriteHAR writes the requests recorded so far as a HAR 1.2 archive, for
+	// browser devtools or debugging proxies. Recording is enabled with
+	// Config.HAR; without it WriteHAR returns an error.
+	//
+	// Example:
+	//    f, _ := os.Create("flow.har")
+	//    defer f.Close()
+	//    err := client.WriteHAR(f)
+	WriteHAR(w io.Writer) error
+
+	// Traffic returns the bytes sent and received so far, in total and per
+	// host, counted on the wire.
+	//
+	// Example:
+	//    stats := client.Traffic()
+	//    fmt.Println(stats.Hosts["api.com"].BytesSent)
+	Traffic() TrafficStats
+
+	// Curl renders the request the call would send as a curl command
+	// without sending it.
+	//
+	// Example:
+	//    cmd, err := client.Curl(http.MethodPost, url, httpx.WithBody(user), httpx.WithCurlRedact())
+	Curl(method, url string, opts ...Option) (string, error)
+
+	// Stream opens a Server-Sent Events stream with GET and delivers its
+	// events on a channel, reconnecting with Last-Event-ID when the
+	// connection drops.
+	//
+	// Example:
+	//    stream, err := client.Stream(url, httpx.WithContext(ctx))
+	//    for event := range stream.Events() { ... }
+	Stream(url string, opts ...Option) (*EventStream, error)
+
+	// HTTPClient returns the underlying *http.Client used for regular
+	// requests, for libraries that need direct access to it. Only this
+	// engine is exposed: requests sent with WithFreshConnection or
+	// WithIdleTimeout use separate engines that changes to it do not reach.
+	//
+	// Example:
+	//    hc := client.HTTPClient()
+	//    hc.Transport = otelhttp.NewTransport(hc.Transport)
+	HTTPClient() *http.Client
+
+	// OnRequest registers an interceptor that runs, in registration order,
+	// before every request is sent. An interceptor returning an error aborts
+	// the call.
+	//
+	// Example:
+	//    client.OnRequest(func(req *http.Request) error {
+	//        req.Header.Set("Authorization", "Bearer "+token)
+	//        return nil
+	//    })
+	OnRequest(fn RequestInterceptor)
+
+	// OnResponse registers an interceptor that runs, in registration order,
+	// after every response is received. It may inspect or mutate the response,
+	// e.g. wrap its body. An interceptor returning an error fails the call.
+	//
+	// Example:
+	//    client.OnResponse(func(res *http.Response) error {
+	//        if res.StatusCode == http.StatusUnauthorized {
+	//            return ErrSessionExpired
+	//        }
+	//        return nil
+	//    })
+	OnResponse(fn ResponseInterceptor)
+
+	// Close stops all background goroutines spawned by the client and waits
+	// for them to exit, bounded by ctx. Idle connections are closed as well.
+	// The client must not be used after Close.
+	//
+	// Example:
+	//    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	//    defer cancel()
+	//    err := client.Close(ctx)
+	Close(ctx context.Context) error
+
+	// Tasks lists the background goroutines currently running on behalf of
+	// the client. It is intended for hunting goroutine leaks.
+	Tasks() []TaskInfo
 }
@@ -0,0 +1,329 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWithInvalidConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := &Config{ClientCertFile: "missing.pem", ClientKeyFile: "missing-key.pem"}
+
+	_, wantErr := NewClient(cfg)
+	if wantErr == nil {
+		t.Fatal("NewClient accepted missing certificate files")
+	}
+
+	// New reports the error on every request instead of panicking
+	c := New(cfg)
+	defer c.Close(context.Background())
+
+	for range 2 {
+		if _, err := c.Get(srv.URL); err == nil || err.Error() != wantErr.Error() {
+			t.Errorf("err = %v, want %v", err, wantErr)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Origin", r.Header.Get("Origin"))
+		w.Header().Set("Allow", "get, POST")
+		w.Header().Add("Allow", "options,GET")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Options(srv.URL,
+		WithParams(map[string]string{"probe": "1"}),
+		WithHeaders(http.Header{"Origin": {"https://app.example.com"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body) != 0 || res.StatusCode != http.StatusNoContent {
+		t.Errorf("got %d with %d body bytes", res.StatusCode, len(body))
+	}
+	for k, want := range map[string]string{"X-Method": http.MethodOptions, "X-Query": "probe=1", "X-Origin": "https://app.example.com"} {
+		if got := res.Header.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if got := AllowedMethods(res); !reflect.DeepEqual(got, []string{"GET", "POST", "OPTIONS"}) {
+		t.Errorf("AllowedMethods = %q", got)
+	}
+
+	// OPTIONS requests carry no body
+	if _, err := c.Options(srv.URL, WithBody("payload")); err == nil {
+		t.Error("OPTIONS with a body was sent")
+	}
+}
+
+// baseTransport returns the http.Transport behind the default engine of c.
+func baseTransport(t *testing.T, c Client) (*http.Transport, *trafficTransport) {
+	t.Helper()
+
+	traffic, ok := c.(*client).httpClient.Transport.(*trafficTransport)
+	if !ok {
+		t.Fatalf("engine transport is %T", c.(*client).httpClient.Transport)
+	}
+	transport, ok := traffic.base.(*http.Transport)
+	if !ok {
+		t.Fatalf("base transport is %T", traffic.base)
+	}
+	return transport, traffic
+}
+
+func TestTransportConfig(t *testing.T) {
+	c := New(&Config{
+		MaxIdleConnections:    7,
+		MaxIdleConns:          42,
+		MaxConnsPerHost:       3,
+		IdleConnTimeout:       time.Minute,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ExpectContinueTimeout: 3 * time.Second,
+		ResponseHeaderTimeout: 4 * time.Second,
+		RequestTimeout:        time.Hour,
+		DisableKeepAlives:     true,
+		DisableCompression:    true,
+	})
+	defer c.Close(context.Background())
+
+	transport, traffic := baseTransport(t, c)
+	got := map[string]any{
+		"MaxIdleConnsPerHost":   transport.MaxIdleConnsPerHost,
+		"MaxIdleConns":          transport.MaxIdleConns,
+		"MaxConnsPerHost":       transport.MaxConnsPerHost,
+		"IdleConnTimeout":       transport.IdleConnTimeout,
+		"TLSHandshakeTimeout":   transport.TLSHandshakeTimeout,
+		"ExpectContinueTimeout": transport.ExpectContinueTimeout,
+		"ResponseHeaderTimeout": transport.ResponseHeaderTimeout,
+		"DisableKeepAlives":     transport.DisableKeepAlives,
+		"decompress":            traffic.decompress,
+	}
+	want := map[string]any{
+		"MaxIdleConnsPerHost":   7,
+		"MaxIdleConns":          42,
+		"MaxConnsPerHost":       3,
+		"IdleConnTimeout":       time.Minute,
+		"TLSHandshakeTimeout":   2 * time.Second,
+		"ExpectContinueTimeout": 3 * time.Second,
+		"ResponseHeaderTimeout": 4 * time.Second,
+		"DisableKeepAlives":     true,
+		"decompress":            false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transport = %v\nwant %v", got, want)
+	}
+}
+
+func TestTransportDefaults(t *testing.T) {
+	// RequestTimeout bounds the whole request, not the header wait
+	c := New(&Config{RequestTimeout: time.Second})
+	defer c.Close(context.Background())
+
+	transport, traffic := baseTransport(t, c)
+	if transport.ResponseHeaderTimeout != 0 {
+		t.Errorf("ResponseHeaderTimeout = %v, want none", transport.ResponseHeaderTimeout)
+	}
+	if transport.MaxIdleConns != 100 || transport.IdleConnTimeout != 90*time.Second ||
+		transport.TLSHandshakeTimeout != 10*time.Second || transport.ExpectContinueTimeout != time.Second {
+		t.Errorf("transport defaults differ from net/http: %+v", transport)
+	}
+	if transport.MaxConnsPerHost != 0 || transport.DisableKeepAlives || !traffic.decompress {
+		t.Errorf("MaxConnsPerHost %d, DisableKeepAlives %v, decompress %v",
+			transport.MaxConnsPerHost, transport.DisableKeepAlives, traffic.decompress)
+	}
+}
+
+func TestDisableCompression(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Accept-Encoding", r.Header.Get("Accept-Encoding"))
+	}))
+	defer srv.Close()
+
+	for _, disable := range []bool{false, true} {
+		c := New(&Config{DisableCompression: disable})
+		defer c.Close(context.Background())
+
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if asked := res.Header.Get("X-Accept-Encoding") == "gzip"; asked == disable {
+			t.Errorf("DisableCompression %v: Accept-Encoding = %q", disable, res.Header.Get("X-Accept-Encoding"))
+		}
+	}
+}
+
+func TestCustomTransport(t *testing.T) {
+	var calls atomic.Int64
+	c := New(&Config{Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	})})
+	defer c.Close(context.Background())
+
+	// Config.Transport serves the requests of every engine
+	for _, opts := range [][]Option{
+		nil,
+		{WithFreshConnection()},
+		{WithIdleTimeout(time.Second)},
+		{WithFreshConnection(), WithIdleTimeout(time.Second)},
+	} {
+		res, err := c.Get("http://api.test/", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("custom transport saw %d requests, want 4", n)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	hc := c.HTTPClient()
+	if hc != c.(*client).httpClient {
+		t.Fatal("HTTPClient() is not the regular engine")
+	}
+
+	// Wrapping its transport instruments regular requests only
+	var wrapped atomic.Int64
+	next := hc.Transport
+	hc.Transport = RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		wrapped.Add(1)
+		return next.RoundTrip(req)
+	})
+
+	for _, opts := range [][]Option{nil, {WithFreshConnection()}} {
+		res, err := c.Get(srv.URL, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if n := wrapped.Load(); n != 1 {
+		t.Errorf("wrapped transport saw %d requests, want only the regular one", n)
+	}
+
+	// The client also works on its own, with the httpx transport stack
+	res, err := hc.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := wrapped.Load(); n != 2 {
+		t.Errorf("wrapped transport saw %d requests, want 2", n)
+	}
+}
+
+func TestCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		}
+		w.Header().Set("X-Cookie", r.Header.Get("Cookie"))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	send := func(c Client, path string, opts ...Option) string {
+		t.Helper()
+		res, err := c.Get(srv.URL+path, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.Header.Get("X-Cookie")
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		send(c, "/login")
+		if got := send(c, "/"); got != "" {
+			t.Errorf("Cookie = %q, want none", got)
+		}
+		if cookies := c.Cookies(u); cookies != nil {
+			t.Errorf("Cookies() = %v, want nil", cookies)
+		}
+	})
+
+	t.Run("EnableCookies", func(t *testing.T) {
+		c := New(&Config{EnableCookies: true})
+		defer c.Close(context.Background())
+
+		send(c, "/login")
+		if got := send(c, "/"); got != "session=abc" {
+			t.Errorf("Cookie = %q, want the session", got)
+		}
+		if cookies := c.Cookies(u); len(cookies) != 1 || cookies[0].Value != "abc" {
+			t.Errorf("Cookies() = %v", cookies)
+		}
+	})
+
+	t.Run("custom CookieJar", func(t *testing.T) {
+		jar, _ := cookiejar.New(nil)
+		jar.SetCookies(u, []*http.Cookie{{Name: "theme", Value: "dark"}})
+
+		c := New(&Config{CookieJar: jar})
+		defer c.Close(context.Background())
+
+		if got := send(c, "/"); got != "theme=dark" {
+			t.Errorf("Cookie = %q, want the jar's cookie", got)
+		}
+	})
+
+	t.Run("WithCookie", func(t *testing.T) {
+		c := New(&Config{EnableCookies: true})
+		defer c.Close(context.Background())
+
+		send(c, "/login")
+		got := send(c, "/", WithCookie(&http.Cookie{Name: "a", Value: "1"}), WithCookie(&http.Cookie{Name: "b", Value: "2"}))
+		if got != "a=1; b=2; session=abc" {
+			t.Errorf("Cookie = %q, want both request cookies and the jar's", got)
+		}
+
+		// Request cookies are not stored
+		if got := send(c, "/"); got != "session=abc" {
+			t.Errorf("next Cookie = %q, want only the session", got)
+		}
+
+		// They also work without a jar
+		plain := New(nil)
+		defer plain.Close(context.Background())
+		if got := send(plain, "/", WithCookie(&http.Cookie{Name: "a", Value: "1"})); got != "a=1" {
+			t.Errorf("Cookie without a jar = %q", got)
+		}
+	})
+}
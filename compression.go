@@ -0,0 +1,213 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Compressor implements a Content-Encoding. NewWriter compresses request
+// bodies; NewReader, if set, decompresses response bodies (see
+// RegisterDecompressor).
+type Compressor struct {
+	// Encoding is the Content-Encoding token, e.g. "gzip" or "zstd".
+	Encoding string
+
+	// NewWriter wraps w with a compressing writer. Closing the returned
+	// writer must flush all pending data to w.
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader wraps r with a decompressing reader.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// GzipCompressor compresses bodies with compress/gzip.
+var GzipCompressor = Compressor{
+	Encoding: "gzip",
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+}
+
+// DeflateCompressor compresses bodies with compress/flate. Its reader accepts
+// both zlib-wrapped data, as required by HTTP, and the raw deflate streams
+// some servers send instead.
+var DeflateCompressor = Compressor{
+	Encoding: "deflate",
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flate.DefaultCompression)
+	},
+	NewReader: newDeflateReader,
+}
+
+// CompressionPolicy decides whether and how request bodies are compressed.
+//
+// Only encoded bodies (WithBody) are compressed; streamed bodies are sent as
+// is. Compression happens before request interceptors run, so interceptors
+// and signers always see the compressed bytes that go over the wire.
+//
+// httpx itself has no dependencies, so encodings beyond gzip and deflate
+// (e.g. zstd or br) are plugged in as additional Compressors.
+type CompressionPolicy struct {
+	// MinSize is the smallest body, in bytes, that gets compressed.
+	// A value of 0 uses a default of 1024.
+	MinSize int
+
+	// ContentTypes lists the compressible media types. Entries may be exact
+	// ("application/json"), a wildcard subtype ("text/*"), or a structured
+	// syntax suffix ("+json"). Anything else, such as images or archives, is
+	// never compressed. Nil uses DefaultCompressibleTypes.
+	ContentTypes []string
+
+	// Compressors lists encodings in order of preference. The first one the
+	// target host has advertised in the Accept-Encoding header of a previous
+	// response is used.
+	Compressors []Compressor
+
+	// Fallback is used when the host has not advertised any of Compressors,
+	// including on the very first request. The zero value uses gzip.
+	Fallback Compressor
+
+	always bool // compress every body regardless of size and type
+}
+
+// DefaultCompressibleTypes are the media types compressed when
+// CompressionPolicy.ContentTypes is nil.
+var DefaultCompressibleTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"application/x-ndjson",
+	"text/*",
+	"+json",
+	"+xml",
+}
+
+// DefaultCompressionPolicy returns a policy compressing JSON, XML, form and
+// text bodies of at least 1 KB with gzip.
+func DefaultCompressionPolicy() *CompressionPolicy {
+	return &CompressionPolicy{
+		MinSize:  1024,
+		Fallback: GzipCompressor,
+	}
+}
+
+// gzipAlways is the policy behind WithGzipBody.
+var gzipAlways = &CompressionPolicy{Fallback: GzipCompressor, always: true}
+
+// compressible reports whether bodies of the given base media type may be
+// compressed under the policy.
+func (p *CompressionPolicy) compressible(contentType string) bool {
+	types := p.ContentTypes
+	if types == nil {
+		types = DefaultCompressibleTypes
+	}
+
+	for _, t := range types {
+		switch {
+		case strings.HasPrefix(t, "+"):
+			if strings.HasSuffix(contentType, t) {
+				return true
+			}
+		case strings.HasSuffix(t, "/*"):
+			if strings.HasPrefix(contentType, strings.TrimSuffix(t, "*")) {
+				return true
+			}
+		case t == contentType:
+			return true
+		}
+	}
+
+	return false
+}
+
+// choose returns the compressor to use for a host that advertised the given
+// encodings.
+func (p *CompressionPolicy) choose(advertised []string) Compressor {
+	for _, c := range p.Compressors {
+		for _, enc := range advertised {
+			if enc == c.Encoding {
+				return c
+			}
+		}
+	}
+
+	if p.Fallback.NewWriter == nil {
+		return GzipCompressor
+	}
+	return p.Fallback
+}
+
+// compress applies the policy to an encoded body. It returns the body to send
+// and the Content-Encoding used, or the original body and "" when the body is
+// not compressed.
+func (p *CompressionPolicy) compress(body []byte, contentType string, advertised []string) ([]byte, string, error) {
+	minSize := p.MinSize
+	if minSize <= 0 {
+		minSize = 1024
+	}
+
+	if !p.always && (len(body) < minSize || !p.compressible(contentType)) {
+		return body, "", nil
+	}
+
+	c := p.choose(advertised)
+
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	// Incompressible payloads are sent as is
+	if !p.always && buf.Len() >= len(body) {
+		return body, "", nil
+	}
+
+	return buf.Bytes(), c.Encoding, nil
+}
+
+// hostEncodings remembers, per host, the encodings a server advertised via
+// the Accept-Encoding response header.
+type hostEncodings struct {
+	m sync.Map // host → []string
+}
+
+// remember records the Accept-Encoding advertised in res, if any.
+func (h *hostEncodings) remember(res *http.Response) {
+	header := res.Header.Get("Accept-Encoding")
+	if header == "" || res.Request == nil {
+		return
+	}
+
+	var encodings []string
+	for _, part := range strings.Split(header, ",") {
+		enc, _, _ := strings.Cut(part, ";")
+		if enc = strings.ToLower(strings.TrimSpace(enc)); enc != "" {
+			encodings = append(encodings, enc)
+		}
+	}
+
+	h.m.Store(res.Request.URL.Host, encodings)
+}
+
+// lookup returns the encodings last advertised by host.
+func (h *hostEncodings) lookup(host string) []string {
+	if v, ok := h.m.Load(host); ok {
+		return v.([]string)
+	}
+	return nil
+}
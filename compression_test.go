@@ -0,0 +1,245 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// compressionServer reports the Content-Encoding, size and SHA-256 of the
+// body it received, and advertises the given Accept-Encoding.
+func compressionServer(t *testing.T, advertise string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+
+		w.Header().Set("X-Content-Encoding", r.Header.Get("Content-Encoding"))
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Body-Length", strconv.Itoa(len(body)))
+		w.Header().Set("X-Body-SHA256", hex.EncodeToString(sum[:]))
+		if advertise != "" {
+			w.Header().Set("Accept-Encoding", advertise)
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fakeZstd claims to be zstd but writes gzip, which is all the tests need to
+// tell the compressors apart.
+var fakeZstd = Compressor{Encoding: "zstd", NewWriter: GzipCompressor.NewWriter}
+
+func TestCompressionPolicy(t *testing.T) {
+	srv := compressionServer(t, "")
+
+	large := map[string]string{"data": strings.Repeat("compressible ", 200)}
+	small := map[string]string{"data": "tiny"}
+	png := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1000)
+
+	tests := []struct {
+		name     string
+		policy   *CompressionPolicy
+		opts     []Option
+		encoding string
+	}{
+		{"large JSON", DefaultCompressionPolicy(), []Option{WithBody(large)}, "gzip"},
+		{"small JSON", DefaultCompressionPolicy(), []Option{WithBody(small)}, ""},
+		{"image", DefaultCompressionPolicy(), []Option{
+			WithBody(png), WithHeaders(http.Header{"Content-Type": {"image/png"}}),
+		}, ""},
+		{"vendor JSON", DefaultCompressionPolicy(), []Option{
+			WithBody(large), WithHeaders(http.Header{"Content-Type": {"application/vnd.api+json"}}),
+		}, "gzip"},
+		{"explicit Content-Encoding", DefaultCompressionPolicy(), []Option{
+			WithBody(large), WithHeaders(http.Header{"Content-Encoding": {"identity"}}),
+		}, "identity"},
+		{"custom types and size", &CompressionPolicy{MinSize: 1, ContentTypes: []string{"image/*"}}, []Option{
+			WithBody(png), WithHeaders(http.Header{"Content-Type": {"image/png"}}),
+		}, "gzip"},
+		{"custom types exclude JSON", &CompressionPolicy{ContentTypes: []string{"text/*"}}, []Option{WithBody(large)}, ""},
+		{"no policy", nil, []Option{WithBody(large)}, ""},
+		{"disabled per request", DefaultCompressionPolicy(), []Option{WithBody(large), WithCompression(nil)}, ""},
+		{"enabled per request", nil, []Option{WithBody(large), WithCompression(DefaultCompressionPolicy())}, "gzip"},
+		{"deflate fallback", &CompressionPolicy{Fallback: DeflateCompressor}, []Option{WithBody(large)}, "deflate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(&Config{Compression: tt.policy})
+			defer c.Close(context.Background())
+
+			var stats RequestStats
+			res, err := c.Post(srv.URL, append(tt.opts, WithStats(&stats))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+
+			// Stats report what the policy did
+			if tt.encoding == "gzip" || tt.encoding == "deflate" {
+				sent, _ := strconv.ParseInt(res.Header.Get("X-Body-Length"), 10, 64)
+				if stats.ContentEncoding != tt.encoding || stats.CompressedBytes != sent || stats.UncompressedBytes <= sent {
+					t.Errorf("stats = %+v for %d bytes sent", stats, sent)
+				}
+			} else if stats.ContentEncoding != "" {
+				t.Errorf("stats report encoding %q", stats.ContentEncoding)
+			}
+		})
+	}
+}
+
+func TestCompressionKeepsContentType(t *testing.T) {
+	srv := compressionServer(t, "")
+
+	c := New(&Config{Compression: DefaultCompressionPolicy()})
+	defer c.Close(context.Background())
+
+	body := strings.Repeat("line\n", 500)
+	res, err := c.Post(srv.URL, WithBody(body), WithHeaders(http.Header{"Content-Type": {"text/plain; charset=utf-8"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server echoes the compressed bytes it received
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _ := io.ReadAll(zr)
+	res.Body.Close()
+
+	if string(decoded) != body {
+		t.Errorf("decoded %d bytes, want the original %d", len(decoded), len(body))
+	}
+	if got := res.Header.Get("X-Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestCompressionUsesAdvertisedEncoding(t *testing.T) {
+	srv := compressionServer(t, "br;q=1.0, ZSTD, gzip")
+
+	c := New(&Config{Compression: &CompressionPolicy{Compressors: []Compressor{fakeZstd, GzipCompressor}}})
+	defer c.Close(context.Background())
+
+	large := WithBody(map[string]string{"data": strings.Repeat("compressible ", 200)})
+
+	// Nothing is known about the host on the first request
+	for _, want := range []string{"gzip", "zstd", "zstd"} {
+		res, err := c.Post(srv.URL, large)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if got := res.Header.Get("X-Content-Encoding"); got != want {
+			t.Errorf("Content-Encoding = %q, want %q", got, want)
+		}
+	}
+
+	// The memory is per host
+	other := compressionServer(t, "")
+	res, err := c.Post(other.URL, large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := res.Header.Get("X-Content-Encoding"); got != "gzip" {
+		t.Errorf("other host: Content-Encoding = %q, want gzip", got)
+	}
+}
+
+func TestCompressionIsSigned(t *testing.T) {
+	srv := compressionServer(t, "")
+
+	var signedHash, signedEncoding string
+	c := New(&Config{
+		Compression: DefaultCompressionPolicy(),
+		Signer: signerFunc(func(req *http.Request, bodyHash string) error {
+			signedHash, signedEncoding = bodyHash, req.Header.Get("Content-Encoding")
+			return nil
+		}),
+	})
+	defer c.Close(context.Background())
+
+	res, err := c.Post(srv.URL, WithBody(map[string]string{"data": strings.Repeat("compressible ", 200)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// The signature covers the compressed bytes on the wire
+	if signedEncoding != "gzip" {
+		t.Errorf("signer saw Content-Encoding %q", signedEncoding)
+	}
+	if got := res.Header.Get("X-Body-SHA256"); signedHash != got {
+		t.Errorf("signed hash %s, server received %s", signedHash, got)
+	}
+}
+
+func TestWithGzipBody(t *testing.T) {
+	srv := compressionServer(t, "")
+
+	// Neither the size nor the Content-Type would pass the default policy
+	c := New(&Config{Compression: DefaultCompressionPolicy()})
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		body        string
+		contentType string
+	}{
+		{"tiny JSON", []Option{WithBody(map[string]string{"a": "1"})}, `{"a":"1"}`, "application/json"},
+		{"binary", []Option{WithBody([]byte("PNG"))}, "PNG", "application/octet-stream"},
+		{"empty text", []Option{WithBody("")}, "", "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Post(srv.URL, append(tt.opts, WithGzipBody())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if got := res.Header.Get("X-Content-Encoding"); got != "gzip" {
+				t.Errorf("Content-Encoding = %q", got)
+			}
+			if got := res.Header.Get("X-Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+
+			// The server echoes a valid gzip stream of the encoded body
+			zr, err := gzip.NewReader(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decoded) != tt.body {
+				t.Errorf("decoded %q, want %q", decoded, tt.body)
+			}
+		})
+	}
+
+	// Streamed bodies cannot be compressed up front
+	if _, err := c.Post(srv.URL, WithBodyReader(strings.NewReader("stream"), -1), WithGzipBody()); err == nil {
+		t.Error("streamed body accepted")
+	}
+}
@@ -2,14 +2,17 @@ package httpx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // do is the internal request executor used by all HTTP verb methods.
@@ -18,22 +21,103 @@ import (
 // body based on Content-Type, appends query parameters, and finally executes the
 // HTTP request using the underlying *http.Client.
 //
-// This method is not exposed publicly; the public API consists of Get, Post,
-// Put, Patch, and Delete.
+// This method is not exposed publicly; the public API consists of Get, Head,
+// Options, Post, Put, Patch, Delete, Request, and Do.
 func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, error) {
 
+	// Owned streams are closed on every path, including early errors
+	if o.BodyOwned {
+		if closer, ok := o.BodyReader.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	// Clients from New with a broken configuration fail every request
+	if c.initErr != nil {
+		return nil, c.initErr
+	}
+
+	// Fill in settings from the selected request profile
+	if err := c.applyProfile(o); err != nil {
+		return nil, err
+	}
+
+	// Relative URLs are resolved against Config.BaseURL
+	uri, err := resolveURL(c.baseURL, uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidURL, uri, errors.Unwrap(err))
+	}
+
+	if err := validateURL(uri); err != nil {
+		return nil, err
+	}
+
 	//────────────────────────────────────────────────────────────
 	// Merge global headers with per-request headers
 	//────────────────────────────────────────────────────────────
 	requestHeaders := make(http.Header)
 
-	// Apply global headers (from Config)
-	for key, values := range c.Headers {
+	// Global and per-request User-Agent headers take precedence
+	if c.UserAgent != "" {
+		requestHeaders.Set("User-Agent", c.UserAgent)
+	}
+
+	// Client credentials from the Config, likewise overridden by any of the below
+	if authorization := c.defaultAuthorization(); authorization != "" {
+		requestHeaders.Set("Authorization", authorization)
+	}
+
+	// Apply global headers (from Config or SetHeaders)
+	for key, values := range c.globalHeaders() {
 		if len(values) > 0 {
 			requestHeaders.Set(key, values[0])
 		}
 	}
 
+	// Refreshed tokens replace static global credentials; requests that bring
+	// their own skip the token entirely
+	if c.tokens != nil && o.Authorization == "" && o.Headers.Get("Authorization") == "" {
+		ctx := o.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		authorization, err := c.tokens.authorization(ctx)
+		if err != nil {
+			return nil, err
+		}
+		requestHeaders.Set("Authorization", authorization)
+	}
+
+	// Tokens from a TokenSource are handled the same way and remembered to
+	// retry once with a fresh token on 401 Unauthorized
+	var sourceToken string
+	if c.TokenSource != nil && o.Authorization == "" && o.Headers.Get("Authorization") == "" {
+		ctx := o.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		var authorization string
+		if authorization, sourceToken, err = c.sourceAuthorization(ctx); err != nil {
+			return nil, err
+		}
+		requestHeaders.Set("Authorization", authorization)
+	}
+
+	// Per-request credentials beat global headers but not per-request ones
+	if o.Authorization != "" {
+		requestHeaders.Set("Authorization", o.Authorization)
+	}
+
+	if o.IfMatch != "" {
+		requestHeaders.Set("If-Match", o.IfMatch)
+	}
+
+	if o.RequestIDEcho != "" && o.Headers.Get(o.RequestIDEcho) == "" {
+		requestHeaders.Set(o.RequestIDEcho, newRequestID())
+	}
+
 	// Override with per-request headers (from options)
 	if o.Headers != nil {
 		for key, values := range o.Headers {
@@ -46,13 +130,31 @@ func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, erro
 	//────────────────────────────────────────────────────────────
 	// Validate body usage
 	//────────────────────────────────────────────────────────────
-	if method == http.MethodGet && o.Body != nil {
-		return nil, fmt.Errorf("GET request cannot contain a body")
+	hasBody := o.Body != nil || o.BodyReader != nil || o.Multipart != nil || o.BodyFactory != nil
+	if hasBody && !o.BodyAllowed && forbidsBody(method) {
+		return nil, fmt.Errorf("%s request cannot contain a body (use WithBodyAllowed to override)", method)
+	}
+
+	if o.Body != nil && o.BodyReader != nil {
+		return nil, fmt.Errorf("WithBody and WithBodyReader cannot be combined")
+	}
+
+	if o.Multipart != nil && (o.Body != nil || o.BodyReader != nil) {
+		return nil, fmt.Errorf("WithMultipart cannot be combined with WithBody or WithBodyReader")
+	}
+
+	if o.BodyFactory != nil && (o.Body != nil || o.BodyReader != nil || o.Multipart != nil) {
+		return nil, fmt.Errorf("WithBodyReaderFactory cannot be combined with other body options")
 	}
 
 	// Assign default Content-Type if a body exists but user didn't specify one.
 	if o.Body != nil && requestHeaders.Get("Content-Type") == "" {
-		requestHeaders.Set("Content-Type", "application/json")
+		requestHeaders.Set("Content-Type", c.defaultContentType(o.Body))
+	}
+
+	// Streamed bodies are opaque bytes unless told otherwise
+	if (o.BodyReader != nil || o.BodyFactory != nil) && requestHeaders.Get("Content-Type") == "" {
+		requestHeaders.Set("Content-Type", "application/octet-stream")
 	}
 
 	// Determine base Content-Type (strip charset or options)
@@ -63,28 +165,56 @@ func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, erro
 	//────────────────────────────────────────────────────────────
 	var requestBody []byte
 
-	if o.Body != nil && method != http.MethodGet {
+	// Encoding can be expensive; a caller that already gave up pays nothing.
+	// The context of the request itself is only created further below.
+	encodeCtx := o.Context
+	if encodeCtx == nil {
+		encodeCtx = context.Background()
+	}
+	if hasBody {
+		if err := encodeCtx.Err(); err != nil {
+			return nil, fmt.Errorf("httpx: encoding request body: %w", err)
+		}
+	}
+
+	// Readers are streamed to the transport instead of being buffered
+	streamBody, streamLength := o.BodyReader, o.BodyLength
+
+	// Factories produce a fresh reader for the first attempt and each retry
+	if o.BodyFactory != nil {
+		r, err := o.BodyFactory()
+		if err != nil {
+			return nil, fmt.Errorf("httpx: creating request body: %w", err)
+		}
+		streamBody = r
+	}
+
+	// Multipart forms are encoded on the fly through a pipe
+	if o.Multipart != nil {
+		boundary := multipartBoundary(o.MultipartBoundary, requestHeaders.Get("Content-Type"))
+		stream, boundary, err := c.newMultipartStream(encodeCtx, o.Multipart, boundary)
+		if err != nil {
+			return nil, err
+		}
+		requestHeaders.Set("Content-Type", multipartContentType(requestHeaders.Get("Content-Type"), boundary))
+		streamBody, streamLength = stream, -1
+	}
+
+	if o.Body != nil {
 		var err error
 
-		switch contentType {
+		switch encodingMediaType(contentType) {
 
 		// JSON ----------------------------------------------------
 		case "application/json":
-			requestBody, err = json.Marshal(o.Body)
+			requestBody, err = c.marshalJSON(o.Body)
 
 		// FORM URLENCODED -----------------------------------------
 		case "application/x-www-form-urlencoded":
-			values := url.Values{}
-
-			switch v := o.Body.(type) {
-			case map[string]string:
-				for k, val := range v {
-					values.Set(k, val)
-				}
-			case url.Values:
-				values = v
-			default:
-				return nil, fmt.Errorf("body must be map[string]string or url.Values for x-www-form-urlencoded")
+			// Accepts map[string]string, url.Values or a tagged struct
+			values, encErr := encodeValues(o.Body, paramFormat{})
+			if encErr != nil {
+				return nil, encErr
 			}
 
 			requestBody = []byte(values.Encode())
@@ -95,47 +225,52 @@ func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, erro
 
 		// MULTIPART FORM DATA -------------------------------------
 		case "multipart/form-data":
-			var b bytes.Buffer
-			writer := multipart.NewWriter(&b)
-
-			// Automatically set boundary in Content-Type
-			requestHeaders.Set("Content-Type", writer.FormDataContentType())
-
 			fields, ok := o.Body.(map[string]any)
 			if !ok {
 				return nil, fmt.Errorf("multipart/form-data requires body = map[string]any")
 			}
 
-			for key, val := range fields {
-				switch cast := val.(type) {
-
-				case []byte:
-					// file upload (raw bytes)
-					part, err := writer.CreateFormFile(key, key)
-					if err != nil {
-						return nil, err
-					}
-					if _, err := part.Write(cast); err != nil {
-						return nil, err
-					}
-
-				case string:
-					// form field value
-					if err := writer.WriteField(key, cast); err != nil {
-						return nil, err
-					}
-
-				default:
-					return nil, fmt.Errorf("unsupported multipart field type %T for key %s", cast, key)
-				}
+			form, streamed, formErr := multipartFromMap(fields)
+			if formErr != nil {
+				return nil, formErr
 			}
 
-			writer.Close()
-			requestBody = b.Bytes()
+			// The boundary is set in Content-Type, other parameters are kept
+			boundary := multipartBoundary(o.MultipartBoundary, requestHeaders.Get("Content-Type"))
+
+			// FilePart readers are streamed, everything else is buffered
+			if streamed {
+				stream, streamBoundary, streamErr := c.newMultipartStream(encodeCtx, form, boundary)
+				if streamErr != nil {
+					return nil, streamErr
+				}
+				requestHeaders.Set("Content-Type", multipartContentType(requestHeaders.Get("Content-Type"), streamBoundary))
+				streamBody, streamLength = stream, -1
+			} else {
+				var b bytes.Buffer
+				writer, writerErr := newMultipartWriter(&b, boundary)
+				if writerErr != nil {
+					return nil, writerErr
+				}
+
+				requestHeaders.Set("Content-Type", multipartContentType(requestHeaders.Get("Content-Type"), writer.Boundary()))
+
+				err = writeMultipart(writer, form)
+				requestBody = b.Bytes()
+			}
 
 		// PLAIN TEXT ----------------------------------------------
 		case "text/plain":
-			requestBody = []byte(fmt.Sprintf("%v", o.Body))
+			switch v := o.Body.(type) {
+			case string:
+				requestBody = []byte(v)
+			case []byte:
+				requestBody = v
+			case io.Reader:
+				streamBody, streamLength = v, -1
+			default:
+				requestBody = []byte(fmt.Sprintf("%v", o.Body))
+			}
 
 		// RAW STREAM / BYTES --------------------------------------
 		case "application/octet-stream":
@@ -143,14 +278,14 @@ func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, erro
 			case []byte:
 				requestBody = v
 			case io.Reader:
-				requestBody, err = io.ReadAll(v)
+				streamBody, streamLength = v, -1
 			default:
 				return nil, fmt.Errorf("octet-stream requires []byte or io.Reader body")
 			}
 
 		// DEFAULT → JSON ------------------------------------------
 		default:
-			requestBody, err = json.Marshal(o.Body)
+			requestBody, err = c.marshalJSON(o.Body)
 		}
 
 		if err != nil {
@@ -158,44 +293,425 @@ func (c *client) do(method, uri string, o *RequestOptions) (*http.Response, erro
 		}
 	}
 
+	// Resolve the retry policy; it decides whether streams need buffering
+	retry := c.Retry
+	if o.Retry != nil {
+		retry = o.Retry
+	}
+
+	if retry != nil && o.MaxRetryDelay > 0 {
+		capped := *retry
+		capped.MaxRetryDelay = o.MaxRetryDelay
+		retry = &capped
+	}
+
+	// Retries must replay the body. Seekable streams rewind themselves, all
+	// other streams are buffered, but only when retries can actually happen.
+	if streamBody != nil && o.BodyFactory == nil && retry != nil && retry.MaxRetries > 0 {
+		if _, seekable := streamBody.(io.Seeker); !seekable {
+			source := streamBody
+			if o.BodyOwned {
+				// Hide Close; owned streams are closed by the deferred call
+				source = struct{ io.Reader }{streamBody}
+			}
+
+			buffered, err := bufferStream(encodeCtx, source)
+			if err != nil {
+				return nil, err
+			}
+			requestBody, streamBody = buffered, nil
+		}
+	}
+
+	//────────────────────────────────────────────────────────────
+	// Compress encoded body according to the compression policy
+	//────────────────────────────────────────────────────────────
+	policy := c.Compression
+	if o.CompressionOverride {
+		policy = o.Compression
+	}
+
+	// Endpoints that require gzip get it for every body
+	if o.GzipBody {
+		if streamBody != nil {
+			return nil, fmt.Errorf("httpx: WithGzipBody cannot compress streamed bodies")
+		}
+		policy = gzipAlways
+	}
+
+	if policy != nil && requestBody != nil && requestHeaders.Get("Content-Encoding") == "" {
+		var host string
+		if u, err := url.Parse(uri); err == nil {
+			host = u.Host
+		}
+
+		original := len(requestBody)
+
+		var encoding string
+		var err error
+		requestBody, encoding, err = policy.compress(requestBody, contentType, c.encodings.lookup(host))
+		if err != nil {
+			return nil, fmt.Errorf("httpx: compressing request body: %w", err)
+		}
+
+		if encoding != "" {
+			requestHeaders.Set("Content-Encoding", encoding)
+		}
+
+		if o.Stats != nil {
+			o.Stats.ContentEncoding = encoding
+			o.Stats.UncompressedBytes = int64(original)
+			o.Stats.CompressedBytes = int64(len(requestBody))
+		}
+	}
+
 	//────────────────────────────────────────────────────────────
 	// Wrap encoded body in an io.Reader
 	//────────────────────────────────────────────────────────────
 	var bodyReader io.Reader
+	var owned *ownedStream
 	if requestBody != nil {
 		bodyReader = bytes.NewBuffer(requestBody)
+	} else if streamBody != nil && o.BodyOwned {
+		owned = &ownedStream{source: streamBody}
+		bodyReader = owned
+	} else if streamBody != nil {
+		bodyReader = streamBody
 	}
 
 	//────────────────────────────────────────────────────────────
 	// Append query parameters (?key=value)
 	//────────────────────────────────────────────────────────────
-	if o.Params != nil {
-		u, err := url.Parse(uri)
-		if err != nil {
-			return nil, err
-		}
-
-		q := u.Query()
-		for key, val := range o.Params {
-			q.Set(key, val)
-		}
+	uri, err = c.buildURL(uri, o)
+	if err != nil {
+		return nil, err
+	}
 
-		u.RawQuery = q.Encode()
-		uri = u.String()
+	// Header transforms see the fully merged headers
+	for _, transform := range o.HeaderTransforms {
+		transform(requestHeaders)
 	}
 
 	//────────────────────────────────────────────────────────────
 	// Construct the *http.Request
 	//────────────────────────────────────────────────────────────
-	req, err := http.NewRequest(method, uri, bodyReader)
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if o.Values != nil {
+		ctx = context.WithValue(ctx, requestValuesKey{}, o.Values)
+	}
+
+	// The per-request timeout context lives until the body is closed
+	cancel := context.CancelFunc(func() {})
+	if o.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+	}
+
+	// Idle timeouts replace the total timeout with a progress watchdog
+	var watchdog *idleWatchdog
+	if o.IdleTimeout > 0 {
+		var stopWatchdog context.CancelFunc
+		watchdog, ctx, stopWatchdog = watchIdle(ctx, o.IdleTimeout)
+
+		cancelTimeout := cancel
+		cancel = func() {
+			stopWatchdog()
+			cancelTimeout()
+		}
+	}
+
+	// Header timeouts only constrain the wait for the response headers
+	var headers *headerTimer
+	if o.ResponseHeaderTimeout > 0 {
+		var stopHeaders context.CancelFunc
+		headers, ctx, stopHeaders = watchHeaders(ctx, o.ResponseHeaderTimeout)
+
+		cancelPrevious := cancel
+		cancel = func() {
+			stopHeaders()
+			cancelPrevious()
+		}
+	}
+
+	// Enterprise proxies that block PUT, PATCH and DELETE see a POST instead
+	wireMethod := overrideMethod(method, c.MethodOverride || o.MethodOverride)
+	if wireMethod != method {
+		requestHeaders.Set(MethodOverrideHeader, method)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, wireMethod, uri, bodyReader)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("unable to create request: %w", err)
 	}
 
+	if streamBody != nil {
+		prepareStream(req, streamBody, streamLength)
+	}
+
+	if o.BodyFactory != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			r, err := o.BodyFactory()
+			if err != nil {
+				return nil, fmt.Errorf("httpx: creating request body: %w", err)
+			}
+			if rc, ok := r.(io.ReadCloser); ok {
+				return rc, nil
+			}
+			return io.NopCloser(r), nil
+		}
+	}
+
+	// Rewound owned streams are still read through the error recorder
+	if owned != nil && req.GetBody != nil {
+		rewind := req.GetBody
+		req.GetBody = func() (io.ReadCloser, error) {
+			if _, err := rewind(); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(owned), nil
+		}
+	}
+
+	if o.Progress != nil {
+		trackUpload(req, o.Progress)
+	}
+
 	req.Header = requestHeaders
 
+	for _, cookie := range o.Cookies {
+		req.AddCookie(cookie)
+	}
+	req = c.withReadOptions(req, o, method)
+
+	if o.Proxy != "" {
+		if req, err = withProxy(req, o.Proxy); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	if o.MaxRedirects > 0 {
+		req = withRedirectLimit(req, o.MaxRedirects)
+	}
+
+	if o.Trace != nil {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), o.Trace))
+	}
+
+	var timings *timingsTracer
+	if o.OnTimings != nil {
+		timings = newTimingsTracer()
+		defer timings.report(o.OnTimings)
+	}
+
+	req, phases := trackPhases(req, timings, o.Stats)
+
+	if watchdog != nil {
+		req = watchdog.trace(req)
+	}
+
+	if headers != nil {
+		req = headers.trace(req)
+	}
+
 	//────────────────────────────────────────────────────────────
 	// Execute request using the underlying http.Client
 	//────────────────────────────────────────────────────────────
-	return c.httpClient.Do(req)
+	httpClient := c.httpClient
+
+	// Fresh connections are dialed by the non-pooling client and closed
+	// after the response instead of being returned to the idle pool.
+	if o.FreshConnection {
+		req.Close = true
+		httpClient = c.freshClient
+	}
+
+	if o.DisableKeepAlive {
+		req.Close = true
+	}
+
+	// Client-wide timeouts do not apply to idle-watched requests or those
+	// that opted out of them
+	if watchdog != nil || o.NoTimeout {
+		httpClient = c.idleClient(httpClient)
+	}
+
+	started := time.Now()
+
+	if err := c.interceptRequest(req); err != nil {
+		cancel()
+		c.reportExchange(req, nil, err, time.Since(started))
+		return nil, err
+	}
+
+	// Signatures cover the request exactly as it goes on the wire
+	if c.Signer != nil {
+		if err := c.Signer.Sign(req, payloadHash(requestBody, streamBody != nil)); err != nil {
+			cancel()
+			err = fmt.Errorf("httpx: signing request: %w", err)
+			c.reportExchange(req, nil, err, time.Since(started))
+			return nil, err
+		}
+	}
+
+	if o.CurlLog != nil {
+		var redact map[string]bool
+		if o.CurlRedact != nil {
+			redact = redactSet(o.CurlRedact)
+		}
+		o.CurlLog(renderCurl(req, requestBody, streamBody != nil, redact))
+	}
+
+	// Client.Curl only renders the request
+	if o.dryRun {
+		cancel()
+		return nil, nil
+	}
+
+	var archived *harEntry
+	if c.har != nil {
+		archived = c.har.begin(req, requestBody, streamBody != nil)
+	}
+
+	if c.debug != nil {
+		c.debug.request(req, requestBody, streamBody != nil)
+	}
+
+	res, err := c.send(httpClient, req, retry)
+	if err == nil && sourceToken != "" && res.StatusCode == http.StatusUnauthorized {
+		res, err = c.retryUnauthorized(httpClient, req, retry, res, sourceToken)
+	}
+	if err != nil {
+		cancel()
+		if archived != nil {
+			c.har.fail(archived, phases, err)
+		}
+		if watchdog != nil {
+			err = watchdog.wrap(err)
+		}
+		if headers != nil {
+			err = headers.wrap(err)
+		}
+		if owned != nil {
+			if srcErr := owned.sourceErr(); srcErr != nil {
+				err = fmt.Errorf("httpx: reading request body: %w", srcErr)
+			}
+		}
+		err = c.tlsPolicyError(err, req)
+		err = c.deadlineError(err, req, o, phases)
+		if c.debug != nil {
+			c.debug.failure(req, err, time.Since(started))
+		}
+		c.reportExchange(req, nil, err, time.Since(started))
+		return nil, err
+	}
+
+	if headers != nil {
+		headers.timer.Stop()
+	}
+
+	phases.enter(phaseReadingBody)
+
+	if c.tlsAudit != nil && res.TLS != nil {
+		c.tlsAudit.observe(res.Request.URL.Host, res.TLS)
+	}
+
+	if watchdog != nil {
+		res.Body = &idleBody{ReadCloser: res.Body, watchdog: watchdog}
+	}
+
+	if archived != nil {
+		res.Body = &harBody{ReadCloser: res.Body, recorder: c.har, entry: archived, res: res, phases: phases}
+	}
+
+	if c.debug != nil {
+		res.Body = &debugBody{ReadCloser: res.Body, dumper: c.debug, res: res, started: started}
+	}
+
+	res.Body = &deadlineBody{ReadCloser: res.Body, client: c, req: req, opts: o, phases: phases}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	c.encodings.remember(res)
+
+	if err := c.interceptResponse(res); err != nil {
+		c.reportExchange(req, nil, err, time.Since(started))
+		return nil, err
+	}
+
+	if o.RequestIDEcho != "" {
+		if err := checkRequestIDEcho(req, res, o.RequestIDEcho); err != nil {
+			c.reportExchange(req, nil, err, time.Since(started))
+			return nil, err
+		}
+	}
+
+	if o.ResponseHook != nil {
+		if res, err = runResponseHook(o.ResponseHook, res); err != nil {
+			c.reportExchange(req, nil, err, time.Since(started))
+			return nil, err
+		}
+	}
+
+	if err := c.validateResponse(res, o.ResponseValidators); err != nil {
+		c.reportExchange(req, nil, err, time.Since(started))
+		return nil, err
+	}
+
+	c.reportExchange(req, res, nil, time.Since(started))
+
+	return res, nil
+}
+
+// marshalJSON encodes v with Config.JSONMarshal or encoding/json.
+func (c *client) marshalJSON(v any) ([]byte, error) {
+	if c.JSONMarshal != nil {
+		return c.JSONMarshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// defaultContentType returns the Content-Type for a body sent without one:
+// Config.DefaultContentType if set, otherwise one inferred from the body type
+// so that strings, bytes, readers and url.Values are not JSON-encoded.
+func (c *client) defaultContentType(body any) string {
+	if c.DefaultContentType != "" {
+		return c.DefaultContentType
+	}
+
+	switch body.(type) {
+	case string:
+		return "text/plain; charset=utf-8"
+	case []byte, io.Reader:
+		return "application/octet-stream"
+	case url.Values:
+		return "application/x-www-form-urlencoded"
+	}
+	return "application/json"
+}
+
+// encodingMediaType maps structured syntax suffixes to the media type whose
+// encoder handles them, so that e.g. application/ld+json and
+// application/vnd.api+json are encoded as JSON and application/atom+xml as
+// XML. Other types are returned unchanged.
+func encodingMediaType(contentType string) string {
+	switch {
+	case strings.HasSuffix(contentType, "+json"):
+		return "application/json"
+	case strings.HasSuffix(contentType, "+xml"):
+		return "application/xml"
+	}
+	return contentType
+}
+
+// forbidsBody reports whether httpx rejects request bodies for method unless
+// WithBodyAllowed is set. Servers disagree on the meaning of such bodies.
+func forbidsBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	}
+	return false
 }
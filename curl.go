@@ -0,0 +1,105 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Curl renders the request that the given call would send as an equivalent
+// curl command, without sending it. Headers, query parameters and the body
+// go through the same merging and encoding as a real request, including
+// request interceptors. Combine it with WithCurlRedact to hide credentials.
+//
+// Example:
+//
+//	cmd, err := client.Curl(http.MethodPost, "https://api.com/users",
+//	    httpx.WithBody(user),
+//	    httpx.WithCurlRedact(),
+//	)
+func (c *client) Curl(method, url string, opts ...Option) (string, error) {
+	if !validMethod(method) {
+		return "", fmt.Errorf("httpx: invalid HTTP method %q", method)
+	}
+
+	o := buildOptions(opts)
+
+	var cmd string
+	o.CurlLog = func(s string) { cmd = s }
+	o.dryRun = true
+
+	if _, err := c.do(method, url, o); err != nil {
+		return "", err
+	}
+	return cmd, nil
+}
+
+// curlRedacted replaces redacted header values in curl commands.
+const curlRedacted = "[REDACTED]"
+
+// renderCurl formats req as a curl command. body is the encoded request body,
+// or nil when the body is streamed from a reader, which cannot be shown
+// without consuming it; streamed and multipart bodies are replaced by a
+// placeholder comment. Text bodies are passed inline; binary bodies are
+// piped in base64-decoded through --data-binary @-.
+func renderCurl(req *http.Request, body []byte, streamed bool, redact map[string]bool) string {
+	var args []string
+
+	switch {
+	case req.Method == http.MethodHead:
+		args = append(args, "--head")
+	case req.Method != http.MethodGet || body != nil || streamed:
+		args = append(args, "-X", req.Method)
+	}
+
+	args = append(args, shellQuote(req.URL.String()))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			if redact[http.CanonicalHeaderKey(name)] {
+				v = curlRedacted
+			}
+			args = append(args, "-H", shellQuote(name+": "+v))
+		}
+	}
+
+	var stdin string
+	switch {
+	case streamed && strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/"):
+		args = append(args, "--data-binary", "@-")
+		stdin = "# multipart body is streamed and not shown; rebuild it with -F\n"
+	case streamed:
+		args = append(args, "--data-binary", "@-")
+		stdin = "# request body is streamed from a reader and not shown\n"
+	case body == nil:
+	case utf8.Valid(body):
+		args = append(args, "--data-binary", shellQuote(string(body)))
+	default:
+		args = append(args, "--data-binary", "@-")
+		stdin = "base64 -d <<'EOF' | "
+	}
+
+	cmd := "curl " + strings.Join(args, " ")
+
+	switch {
+	case strings.HasPrefix(stdin, "#"):
+		return stdin + cmd
+	case stdin != "":
+		return stdin + cmd + "\n" + base64.StdEncoding.EncodeToString(body) + "\nEOF"
+	}
+	return cmd
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
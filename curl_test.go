@@ -0,0 +1,71 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCurlJSONPost(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	c := New(&Config{BearerToken: "t0k3n", Headers: http.Header{"X-Team": {"it's"}}})
+	defer c.Close(context.Background())
+
+	cmd, err := c.Curl(http.MethodPost, srv.URL+"/users",
+		WithParams(map[string]string{"notify": "true"}),
+		WithBody(map[string]string{"name": "O'Brien"}),
+		WithCurlRedact(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "curl -X POST '" + srv.URL + "/users?notify=true'" +
+		" -H 'Authorization: [REDACTED]'" +
+		" -H 'Content-Type: application/json'" +
+		` -H 'X-Team: it'\''s'` +
+		` --data-binary '{"name":"O'\''Brien"}'`
+	if cmd != want {
+		t.Errorf("curl command:\n%s\nwant:\n%s", cmd, want)
+	}
+	if hits.Load() != 0 {
+		t.Error("Curl sent the request")
+	}
+}
+
+func TestCurlBodies(t *testing.T) {
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"binary", []Option{WithBody([]byte{0xff, 0x00})},
+			"base64 -d <<'EOF' | curl -X POST 'http://api.test/' -H 'Content-Type: application/octet-stream' --data-binary @-\n/wA=\nEOF"},
+		{"stream", []Option{WithBodyReader(strings.NewReader("data"), -1)},
+			"# request body is streamed from a reader and not shown\ncurl -X POST 'http://api.test/' -H 'Content-Type: application/octet-stream' --data-binary @-"},
+		{"multipart", []Option{WithMultipart(map[string]string{"a": "1"}), WithMultipartBoundary("b")},
+			"# multipart body is streamed and not shown; rebuild it with -F\ncurl -X POST 'http://api.test/' -H 'Content-Type: multipart/form-data; boundary=b' --data-binary @-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := c.Curl(http.MethodPost, "http://api.test/", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd != tt.want {
+				t.Errorf("curl command:\n%s\nwant:\n%s", cmd, tt.want)
+			}
+		})
+	}
+}
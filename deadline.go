@@ -0,0 +1,300 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PhaseTiming is the duration of a completed request phase.
+type PhaseTiming struct {
+	Phase    string        // e.g. "dns", "dialing", "tls", "waiting for headers"
+	Duration time.Duration // time spent in the phase
+}
+
+// DeadlineError is returned when a request fails because a deadline expired,
+// including while the response body is read. It names the phase that was in
+// progress, the durations of the phases that completed before, and which
+// timeout fired with its budget.
+//
+// DeadlineError matches context.DeadlineExceeded via errors.Is, and
+// ErrIdleTimeout when the idle timeout fired.
+type DeadlineError struct {
+	Method    string        // HTTP method of the request
+	URL       string        // Request URL
+	Phase     string        // Phase in progress when the deadline expired
+	Completed []PhaseTiming // Phases that completed before, in order
+	Elapsed   time.Duration // Total time from start until the failure
+	Timeout   string        // Which timeout fired, e.g. "WithTimeout" or "body idle"
+	Budget    time.Duration // Configured budget of that timeout, zero if unknown
+	Err       error         // Underlying error from the transport
+}
+
+// Error implements the error interface.
+func (e *DeadlineError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "httpx: %s %s: deadline exceeded while %s after %s (%s",
+		e.Method, e.URL, e.Phase, e.Elapsed.Round(time.Millisecond), e.Timeout)
+	if e.Budget > 0 {
+		fmt.Fprintf(&b, " budget %s", e.Budget)
+	}
+	b.WriteString(")")
+
+	if len(e.Completed) > 0 {
+		parts := make([]string, len(e.Completed))
+		for i, p := range e.Completed {
+			parts[i] = fmt.Sprintf("%s %s", p.Phase, p.Duration.Round(time.Microsecond))
+		}
+		fmt.Fprintf(&b, "; completed: %s", strings.Join(parts, ", "))
+	}
+
+	return b.String()
+}
+
+// Unwrap exposes context.DeadlineExceeded and the transport error.
+func (e *DeadlineError) Unwrap() []error {
+	return []error{context.DeadlineExceeded, e.Err}
+}
+
+// Request phases reported by DeadlineError.
+const (
+	phaseQueued         = "queued"
+	phaseDNS            = "resolving DNS"
+	phaseDialing        = "dialing"
+	phaseTLS            = "TLS handshaking"
+	phaseWriting        = "writing request"
+	phaseWaitingHeaders = "waiting for headers"
+	phaseReadingHeaders = "reading headers"
+	phaseReadingBody    = "reading body"
+	phaseRetryBackoff   = "waiting for retry"
+)
+
+// phaseTracker follows a request through its phases using httptrace.
+type phaseTracker struct {
+	mu         sync.Mutex
+	start      time.Time
+	phase      string
+	phaseStart time.Time
+	completed  []PhaseTiming
+}
+
+// phaseTrackerKey is the context key under which the tracker is stored.
+type phaseTrackerKey struct{}
+
+// trackPhases attaches a phase tracker to req and returns both. The same
+// client trace feeds the optional timings of WithTimings and stats of
+// WithStats, so a request carries a single trace however many are enabled.
+func trackPhases(req *http.Request, timings *timingsTracer, stats *RequestStats) (*http.Request, *phaseTracker) {
+	now := time.Now()
+	p := &phaseTracker{start: now, phase: phaseQueued, phaseStart: now}
+
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { timings.getConn() },
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.enter(phaseDNS)
+			timings.startDNS()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) { timings.endDNS() },
+		ConnectStart: func(string, string) {
+			p.enter(phaseDialing)
+			timings.startDial()
+		},
+		ConnectDone: func(_, _ string, err error) { timings.endDial(err) },
+		TLSHandshakeStart: func() {
+			p.enter(phaseTLS)
+			timings.startTLS()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) { timings.endTLS(err) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.enter(phaseWriting)
+			timings.gotConn(info)
+			if stats != nil {
+				stats.FreshConnection = !info.Reused
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { p.enter(phaseWaitingHeaders) },
+		GotFirstResponseByte: func() {
+			p.enter(phaseReadingHeaders)
+			timings.gotFirstResponseByte()
+		},
+	}
+
+	ctx := context.WithValue(req.Context(), phaseTrackerKey{}, p)
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
+	return req.WithContext(ctx), p
+}
+
+// phaseTrackerFrom returns the tracker stored in ctx, or nil.
+func phaseTrackerFrom(ctx context.Context) *phaseTracker {
+	p, _ := ctx.Value(phaseTrackerKey{}).(*phaseTracker)
+	return p
+}
+
+// enter completes the current phase and starts the next one.
+func (p *phaseTracker) enter(phase string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.phase == phase {
+		return
+	}
+
+	now := time.Now()
+	p.completed = append(p.completed, PhaseTiming{Phase: p.phase, Duration: now.Sub(p.phaseStart)})
+	p.phase = phase
+	p.phaseStart = now
+}
+
+// snapshot returns the current phase and a copy of the completed phases.
+func (p *phaseTracker) snapshot() (string, []PhaseTiming) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	completed := make([]PhaseTiming, len(p.completed))
+	copy(completed, p.completed)
+	return p.phase, completed
+}
+
+// timings returns the completed phases followed by the phase in progress,
+// measured up to now.
+func (p *phaseTracker) timings() []PhaseTiming {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	timings := make([]PhaseTiming, len(p.completed), len(p.completed)+1)
+	copy(timings, p.completed)
+	return append(timings, PhaseTiming{Phase: p.phase, Duration: time.Since(p.phaseStart)})
+}
+
+// isTimeout reports whether err was caused by an expired deadline.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// deadlineError converts a timeout failure of req into a DeadlineError that
+// names the phase and the timeout that fired. Other errors are returned as is.
+//
+// Timeouts that span all attempts are reported as "retry budget" when they
+// fire after a retry, since the earlier attempts used up part of the budget.
+func (c *client) deadlineError(err error, req *http.Request, o *RequestOptions, p *phaseTracker) error {
+	if err == nil || !(isTimeout(err) || errors.Is(err, ErrIdleTimeout)) {
+		return err
+	}
+	var already *DeadlineError
+	if errors.As(err, &already) {
+		return err
+	}
+
+	phase, completed := p.snapshot()
+	de := &DeadlineError{
+		Method:    LogicalMethod(req),
+		URL:       req.URL.String(),
+		Phase:     phase,
+		Completed: completed,
+		Elapsed:   time.Since(p.start),
+		Err:       err,
+	}
+
+	switch {
+	case errors.Is(err, ErrIdleTimeout) && phase == phaseReadingBody:
+		de.Timeout = "body idle"
+		de.Budget = o.IdleTimeout
+	case errors.Is(err, ErrIdleTimeout):
+		de.Timeout = "WithIdleTimeout"
+		de.Budget = o.IdleTimeout
+	case o.Context != nil && o.Context.Err() == context.DeadlineExceeded:
+		de.Timeout = "caller context"
+		if deadline, ok := o.Context.Deadline(); ok {
+			de.Budget = deadline.Sub(p.start).Round(time.Millisecond)
+		}
+	case o.Timeout > 0 && req.Context().Err() == context.DeadlineExceeded:
+		de.Timeout = "WithTimeout"
+		de.Budget = o.Timeout
+	case phase == phaseDialing && c.ConnectionTimeout > 0:
+		de.Timeout = "ConnectionTimeout"
+		de.Budget = c.ConnectionTimeout
+	case phase == phaseTLS && c.TLSHandshakeTimeout > 0 && !c.requestTimedOut(de.Elapsed):
+		de.Timeout = "TLSHandshakeTimeout"
+		de.Budget = c.TLSHandshakeTimeout
+	case phase == phaseWaitingHeaders && c.ResponseHeaderTimeout > 0 && !c.requestTimedOut(de.Elapsed):
+		de.Timeout = "ResponseHeaderTimeout"
+		de.Budget = c.ResponseHeaderTimeout
+	case c.RequestTimeout > 0 && !o.NoTimeout && o.IdleTimeout == 0:
+		de.Timeout = "RequestTimeout"
+		de.Budget = c.RequestTimeout
+	default:
+		de.Timeout = "transport timeout"
+	}
+
+	if (de.Timeout == "WithTimeout" || de.Timeout == "RequestTimeout") && retried(completed) {
+		de.Timeout = "retry budget"
+	}
+
+	return de
+}
+
+// retried reports whether completed includes a wait for a retry.
+func retried(completed []PhaseTiming) bool {
+	for _, p := range completed {
+		if p.Phase == phaseRetryBackoff {
+			return true
+		}
+	}
+	return false
+}
+
+// requestTimedOut reports whether a request running for elapsed has used up
+// the client-wide RequestTimeout, which then explains a timeout better than
+// the transport timeouts.
+func (c *client) requestTimedOut(elapsed time.Duration) bool {
+	return c.RequestTimeout > 0 && elapsed >= c.RequestTimeout
+}
+
+// deadlineBody reports deadline expiries while the response body is read as
+// a DeadlineError, like those of the request itself.
+type deadlineBody struct {
+	io.ReadCloser
+	client *client
+	req    *http.Request
+	opts   *RequestOptions
+	phases *phaseTracker
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.client.deadlineError(err, b.req, b.opts, b.phases)
+	}
+	return n, err
+}
+
+// cancelOnClose releases a request-scoped context once the caller is done
+// with the response body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowServer waits before the headers, or between two body chunks.
+func slowServer(t *testing.T, beforeHeaders, beforeBody time.Duration) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(beforeHeaders):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-time.After(beforeBody):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte("second"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDeadlineErrorPhases(t *testing.T) {
+	tests := []struct {
+		name          string
+		beforeHeaders time.Duration
+		beforeBody    time.Duration
+		opts          []Option
+		wantPhase     string
+		wantTimeout   string
+	}{
+		{
+			name:          "waiting for headers",
+			beforeHeaders: time.Second,
+			opts:          []Option{WithTimeout(50 * time.Millisecond)},
+			wantPhase:     phaseWaitingHeaders,
+			wantTimeout:   "WithTimeout",
+		},
+		{
+			name:        "reading body",
+			beforeBody:  time.Second,
+			opts:        []Option{WithTimeout(50 * time.Millisecond)},
+			wantPhase:   phaseReadingBody,
+			wantTimeout: "WithTimeout",
+		},
+		{
+			name:        "body idle",
+			beforeBody:  time.Second,
+			opts:        []Option{WithIdleTimeout(50 * time.Millisecond)},
+			wantPhase:   phaseReadingBody,
+			wantTimeout: "body idle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := slowServer(t, tt.beforeHeaders, tt.beforeBody)
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err == nil {
+				_, err = io.ReadAll(res.Body)
+				res.Body.Close()
+			}
+
+			var de *DeadlineError
+			if !errors.As(err, &de) {
+				t.Fatalf("err = %v, want *DeadlineError", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Error("err does not match context.DeadlineExceeded")
+			}
+			if de.Phase != tt.wantPhase || de.Timeout != tt.wantTimeout {
+				t.Errorf("phase %q, timeout %q; want %q, %q", de.Phase, de.Timeout, tt.wantPhase, tt.wantTimeout)
+			}
+			if de.Budget != 50*time.Millisecond {
+				t.Errorf("budget = %v, want 50ms", de.Budget)
+			}
+		})
+	}
+}
+
+func TestDeadlineErrorBodyIdleMatchesErrIdleTimeout(t *testing.T) {
+	srv := slowServer(t, 0, time.Second)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if _, err := io.ReadAll(res.Body); !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("err = %v, want ErrIdleTimeout", err)
+	}
+}
+
+func TestDeadlineErrorRetryBudget(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 1, Backoff: 10 * time.Millisecond}})
+	defer c.Close(context.Background())
+
+	_, err := c.Get(srv.URL, WithTimeout(100*time.Millisecond))
+
+	var de *DeadlineError
+	if !errors.As(err, &de) {
+		t.Fatalf("err = %v, want *DeadlineError", err)
+	}
+	if de.Timeout != "retry budget" || de.Budget != 100*time.Millisecond {
+		t.Errorf("timeout %q budget %v, want retry budget of 100ms", de.Timeout, de.Budget)
+	}
+}
@@ -0,0 +1,190 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DebugConfig dumps every request and response in a readable form, as
+// httputil.DumpRequestOut and DumpResponse would, without consuming the
+// response body for the caller.
+//
+// The request is written before it is sent. The response is written once its
+// body is closed, with the part the caller read; failed requests are written
+// with their error. Values of RedactHeaders are replaced by "[REDACTED]".
+type DebugConfig struct {
+	// Writer receives the dumps. Defaults to os.Stderr.
+	Writer io.Writer
+
+	// MaxBodySize caps the bytes shown per request and response body.
+	// A value of 0 uses 4 KB; a negative value omits bodies entirely.
+	MaxBodySize int
+
+	// RedactHeaders lists headers whose values are hidden. Nil uses
+	// Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+}
+
+// defaultRedactedHeaders are hidden by HAR recording and debug dumps unless
+// configured otherwise.
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactSet returns the canonical header names to redact, using the default
+// list when names is nil.
+func redactSet(names []string) map[string]bool {
+	if names == nil {
+		names = defaultRedactedHeaders
+	}
+
+	redact := make(map[string]bool, len(names))
+	for _, name := range names {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+	return redact
+}
+
+// debugDumper writes request and response dumps. Each dump is a single
+// write under mu, so concurrent requests do not interleave.
+type debugDumper struct {
+	cfg    DebugConfig
+	redact map[string]bool
+
+	mu sync.Mutex
+}
+
+// newDebugDumper returns a dumper applying the defaults of cfg.
+func newDebugDumper(cfg DebugConfig) *debugDumper {
+	if cfg.Writer == nil {
+		cfg.Writer = os.Stderr
+	}
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 4 << 10
+	}
+
+	return &debugDumper{cfg: cfg, redact: redactSet(cfg.RedactHeaders)}
+}
+
+// write emits one dump.
+func (d *debugDumper) write(b *bytes.Buffer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.cfg.Writer.Write(b.Bytes())
+}
+
+// request dumps an outgoing request. body is the encoded request body, or
+// nil when the body is streamed.
+func (d *debugDumper) request(req *http.Request, body []byte, streamed bool) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "> %s %s %s\n", req.Method, req.URL, req.Proto)
+	d.headers(&b, ">", req.Header)
+
+	switch {
+	case streamed:
+		b.WriteString("[streamed body not shown]\n")
+	case req.Header.Get("Content-Encoding") != "" && len(body) > 0:
+		fmt.Fprintf(&b, "[%d bytes of %s-encoded body not shown]\n", len(body), req.Header.Get("Content-Encoding"))
+	default:
+		d.body(&b, body, int64(len(body)))
+	}
+
+	b.WriteString("\n")
+	d.write(&b)
+}
+
+// failure dumps a request that did not produce a response.
+func (d *debugDumper) failure(req *http.Request, err error, elapsed time.Duration) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "< %s %s failed after %s: %v\n\n", req.Method, req.URL, elapsed.Round(time.Millisecond), err)
+	d.write(&b)
+}
+
+// response dumps res with the captured part of its body.
+func (d *debugDumper) response(res *http.Response, elapsed time.Duration, captured []byte, size int64) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "< %s %s (%s %s, %s)\n", res.Proto, res.Status, res.Request.Method, res.Request.URL, elapsed.Round(time.Millisecond))
+	d.headers(&b, "<", res.Header)
+	d.body(&b, captured, size)
+
+	b.WriteString("\n")
+	d.write(&b)
+}
+
+// headers writes h in sorted order with redaction applied.
+func (d *debugDumper) headers(b *bytes.Buffer, prefix string, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range h[name] {
+			if d.redact[http.CanonicalHeaderKey(name)] {
+				v = harRedacted
+			}
+			fmt.Fprintf(b, "%s %s: %s\n", prefix, name, v)
+		}
+	}
+	fmt.Fprintf(b, "%s\n", prefix)
+}
+
+// body writes up to MaxBodySize bytes of a body of the given total size.
+func (d *debugDumper) body(b *bytes.Buffer, body []byte, size int64) {
+	if d.cfg.MaxBodySize < 0 || size == 0 {
+		return
+	}
+
+	shown := body[:min(len(body), d.cfg.MaxBodySize)]
+	if !utf8.Valid(shown) {
+		fmt.Fprintf(b, "[%d bytes of binary body not shown]\n", size)
+		return
+	}
+
+	b.Write(shown)
+	if int64(len(shown)) < size {
+		fmt.Fprintf(b, "\n[... %d more bytes]", size-int64(len(shown)))
+	}
+	b.WriteString("\n")
+}
+
+// debugBody captures the start of a response body as the caller reads it
+// and dumps the response when the body is closed.
+type debugBody struct {
+	io.ReadCloser
+	dumper  *debugDumper
+	res     *http.Response
+	started time.Time
+
+	captured []byte
+	size     int64
+	once     sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	if room := b.dumper.cfg.MaxBodySize - len(b.captured); room > 0 && n > 0 {
+		b.captured = append(b.captured, p[:min(n, room)]...)
+	}
+
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.dumper.response(b.res, time.Since(b.started), b.captured, b.size)
+	})
+	return err
+}
@@ -0,0 +1,62 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugDump(t *testing.T) {
+	payload := strings.Repeat("0123456789", 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	c := New(&Config{Debug: &DebugConfig{Writer: &out, MaxBodySize: 64}})
+	defer c.Close(context.Background())
+
+	res, err := c.Post(srv.URL,
+		WithBody("hello"),
+		WithBearerToken("token-secret"),
+		WithHeaders(http.Header{"Cookie": {"session=client-secret"}, "X-Trace": {"abc"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dump tees the body; the caller still reads all of it
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != payload {
+		t.Errorf("caller read %d bytes, want the full %d", len(body), len(payload))
+	}
+
+	dump := out.String()
+	for _, want := range []string{
+		"> POST " + srv.URL,
+		"> Authorization: [REDACTED]",
+		"> Cookie: [REDACTED]",
+		"> X-Trace: abc",
+		"hello\n",
+		"< HTTP/1.1 200 OK",
+		"< Set-Cookie: [REDACTED]",
+		payload[:64] + "\n[... 9936 more bytes]",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+	for _, secret := range []string{"token-secret", "client-secret", "server-secret"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("dump leaks %q:\n%s", secret, dump)
+		}
+	}
+}
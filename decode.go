@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Codec describes a body format that response helpers can decode.
+type Codec struct {
+	Name      string                  // Short format name used in error messages
+	Unmarshal func([]byte, any) error // Decodes raw bytes into the target
+}
+
+// JSONCodec decodes bodies using encoding/json.
+var JSONCodec = Codec{Name: "json", Unmarshal: json.Unmarshal}
+
+// XMLCodec decodes bodies using encoding/xml.
+var XMLCodec = Codec{Name: "xml", Unmarshal: xml.Unmarshal}
+
+// DecodeAny decodes the response body into a generic Go type T by trying the
+// given codecs in order until one succeeds. It is intended for inconsistent
+// APIs that answer with different formats for the same endpoint.
+//
+// When no codecs are given, JSON is tried first, with Config.JSONUnmarshal if
+// set, and XML second. If every codec fails, the returned error joins the
+// individual decoding errors. Non-2xx responses return an HttpError before
+// any decoding is attempted.
+//
+// Example:
+//
+//	user, err := httpx.DecodeAny[User](res, httpx.JSONCodec, httpx.XMLCodec)
+func DecodeAny[T any](res *http.Response, codecs ...Codec) (T, error) {
+	var out T
+
+	b, err := readBodyForDecode(res)
+	if err != nil {
+		return out, err
+	}
+
+	if isEmptyHead(res, b) {
+		return out, nil
+	}
+
+	if len(codecs) == 0 {
+		codecs = []Codec{readOptionsFor(res).jsonCodec(), XMLCodec}
+	}
+
+	var errs []error
+	for _, codec := range codecs {
+		var candidate T
+		if err := codec.Unmarshal(b, &candidate); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", codec.Name, err))
+			continue
+		}
+		return candidate, nil
+	}
+
+	return out, fmt.Errorf("httpx: failed to decode body with any codec: %w", errors.Join(errs...))
+}
+
+// ErrUnsupportedContentType is returned by Decode when the response
+// Content-Type is neither JSON nor XML.
+var ErrUnsupportedContentType = errors.New("httpx: unsupported content type")
+
+// Decode decodes the response body into a generic Go type T according to its
+// Content-Type: application/json and +json types as JSON, application/xml,
+// text/xml and +xml types as XML. Other or missing types fail with an error
+// wrapping ErrUnsupportedContentType that names the type.
+//
+// Non-2xx responses return an HttpError before the Content-Type is looked at.
+//
+// Example:
+//
+//	user, err := httpx.Decode[User](res)
+//	if errors.Is(err, httpx.ErrUnsupportedContentType) { ... }
+func Decode[T any](res *http.Response) (T, error) {
+	var out T
+
+	b, err := readBodyForDecode(res)
+	if err != nil {
+		return out, err
+	}
+	defer readOptionsFor(res).release()
+
+	if len(b) == 0 {
+		return out, nil
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	codec, ok := codecForContentType(contentType)
+	if !ok {
+		return out, fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+	if codec.Name == JSONCodec.Name {
+		codec = readOptionsFor(res).jsonCodec()
+	}
+
+	if err := codec.Unmarshal(b, &out); err != nil {
+		return out, fmt.Errorf("httpx: failed to decode %s: %w", strings.ToUpper(codec.Name), err)
+	}
+
+	return out, nil
+}
+
+// NegotiateAccept is the Accept header sent by Negotiate: JSON preferred,
+// XML accepted.
+const NegotiateAccept = "application/json, application/xml;q=0.9, text/xml;q=0.8"
+
+// Negotiate sends a request that accepts both JSON and XML and decodes the
+// response into T according to the Content-Type the server chose, including
+// +json and +xml suffixes such as application/problem+json. Responses with
+// another or no Content-Type are decoded like DecodeAny. An Accept header set
+// via WithHeaders is kept.
+//
+// Non-2xx responses return an HttpError. The response body is closed.
+//
+// Example:
+//
+//	user, err := httpx.Negotiate[User](client, http.MethodGet, "https://api.com/users/1")
+func Negotiate[T any](c Client, method, url string, opts ...Option) (T, error) {
+	var out T
+
+	opts = append(opts[:len(opts):len(opts)], withDefaultAccept(NegotiateAccept))
+	res, err := c.Do(method, url, opts...)
+	if err != nil {
+		return out, err
+	}
+	defer res.Body.Close()
+
+	b, err := readBodyForDecode(res)
+	if err != nil {
+		return out, err
+	}
+
+	if len(b) == 0 {
+		return out, nil
+	}
+
+	codec, ok := codecForContentType(res.Header.Get("Content-Type"))
+	if !ok {
+		return DecodeAny[T](res)
+	}
+	if codec.Name == JSONCodec.Name {
+		codec = readOptionsFor(res).jsonCodec()
+	}
+
+	if err := codec.Unmarshal(b, &out); err != nil {
+		return out, fmt.Errorf("httpx: failed to decode %s: %w", strings.ToUpper(codec.Name), err)
+	}
+
+	return out, nil
+}
+
+// codecForContentType returns the codec for a JSON or XML media type. It
+// reports false for empty or other media types.
+func codecForContentType(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Codec{}, false
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JSONCodec, true
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XMLCodec, true
+	}
+	return Codec{}, false
+}
+
+// withDefaultAccept sets the Accept header unless the request already has
+// one. The header map passed to WithHeaders is not modified.
+func withDefaultAccept(accept string) Option {
+	return func(o *RequestOptions) {
+		if o.Headers.Get("Accept") != "" {
+			return
+		}
+
+		h := o.Headers.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Set("Accept", accept)
+		o.Headers = h
+	}
+}
@@ -0,0 +1,249 @@
+package httpx
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// contentServer answers every request with body as contentType.
+func contentServer(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type decodedUser struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func TestDecodeAnyFallsBackToXML(t *testing.T) {
+	// The Content-Type claims JSON, but the body is XML
+	srv := contentServer(t, "application/json", "<user><name>John</name></user>")
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := DecodeAny[decodedUser](res, JSONCodec, XMLCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "John" {
+		t.Errorf("Name = %q", user.Name)
+	}
+}
+
+func TestDecodeAny(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		codecs  []Codec
+		want    string
+		wantErr bool
+	}{
+		{"default codecs, JSON", `{"name":"John"}`, nil, "John", false},
+		{"default codecs, XML", "<user><name>Jane</name></user>", nil, "Jane", false},
+		{"first codec wins", `{"name":"John"}`, []Codec{JSONCodec, XMLCodec}, "John", false},
+		{"only XML", `{"name":"John"}`, []Codec{XMLCodec}, "", true},
+		{"neither", "not a document", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := contentServer(t, "", tt.body)
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := DecodeAny[decodedUser](res, tt.codecs...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decoding succeeded")
+				}
+				// The aggregate error names every codec that failed
+				for _, codec := range tt.codecs {
+					if !strings.Contains(err.Error(), codec.Name+":") {
+						t.Errorf("error %q does not name codec %s", err, codec.Name)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != tt.want {
+				t.Errorf("Name = %q, want %q", user.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeAnyHttpError(t *testing.T) {
+	srv := encodedServer(t, "", http.StatusNotFound, []byte(`{"name":"John"}`))
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var httpErr *HttpError
+	if _, err := DecodeAny[decodedUser](res); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("err = %v, want an HttpError for 404", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+		body        string
+		want        string
+		wantErr     error
+	}{
+		{"JSON", "application/json", http.StatusOK, `{"name":"John"}`, "John", nil},
+		{"JSON with charset", "application/json; charset=utf-8", http.StatusOK, `{"name":"John"}`, "John", nil},
+		{"+json", "application/vnd.api+json", http.StatusOK, `{"name":"John"}`, "John", nil},
+		{"XML", "application/xml", http.StatusOK, "<user><name>Jane</name></user>", "Jane", nil},
+		{"text/xml", "text/xml", http.StatusOK, "<user><name>Jane</name></user>", "Jane", nil},
+		{"+xml", "application/atom+xml", http.StatusOK, "<user><name>Jane</name></user>", "Jane", nil},
+		{"empty body", "application/json", http.StatusOK, "", "", nil},
+		{"unknown type", "text/csv", http.StatusOK, "name\nJohn\n", "", ErrUnsupportedContentType},
+		{"missing type", "", http.StatusOK, `{"name":"John"}`, "", ErrUnsupportedContentType},
+		{"non-2xx JSON", "application/json", http.StatusNotFound, `{"name":"John"}`, "", &HttpError{}},
+		{"non-2xx unknown type", "text/csv", http.StatusBadGateway, "upstream down", "", &HttpError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Without a type net/http would sniff one
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := Decode[decodedUser](res)
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if user.Name != tt.want {
+					t.Errorf("Name = %q, want %q", user.Name, tt.want)
+				}
+			case *HttpError:
+				var httpErr *HttpError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+					t.Errorf("err = %v, want an HttpError for %d", err, tt.status)
+				}
+			default:
+				if !errors.Is(err, want) || !strings.Contains(err.Error(), fmt.Sprintf("%q", tt.contentType)) {
+					t.Errorf("err = %v, want %v naming %q", err, want, tt.contentType)
+				}
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"JSON", "application/json; charset=utf-8", `{"name":"John"}`},
+		{"XML", "application/xml", "<user><name>John</name></user>"},
+		{"text XML", "text/xml", "<user><name>John</name></user>"},
+		{"JSON suffix", "application/vnd.user+json", `{"name":"John"}`},
+		{"XML suffix", "application/vnd.user+xml", "<user><name>John</name></user>"},
+		{"no Content-Type", "", "<user><name>John</name></user>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = r.Header.Get("Accept")
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			user, err := Negotiate[decodedUser](c, http.MethodGet, srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != "John" {
+				t.Errorf("Name = %q", user.Name)
+			}
+			if accept != NegotiateAccept {
+				t.Errorf("Accept = %q", accept)
+			}
+		})
+	}
+}
+
+func TestNegotiateKeepsAccept(t *testing.T) {
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte("<user><name>John</name></user>"))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	_, err := Negotiate[decodedUser](c, http.MethodGet, srv.URL, WithHeaders(http.Header{"Accept": {"application/xml"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != "application/xml" {
+		t.Errorf("Accept = %q, want the caller's", accept)
+	}
+
+	// The Content-Type decides the codec; a mismatching body is an error
+	bad := contentServer(t, "application/json", "<user><name>John</name></user>")
+	if _, err := Negotiate[decodedUser](c, http.MethodGet, bad.URL); err == nil || !strings.Contains(err.Error(), "JSON") {
+		t.Errorf("err = %v, want a JSON decode error", err)
+	}
+}
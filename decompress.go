@@ -0,0 +1,153 @@
+package httpx
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// decompressors holds the response decoders keyed by Content-Encoding.
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]Compressor{
+		"gzip":    GzipCompressor,
+		"x-gzip":  GzipCompressor,
+		"deflate": DeflateCompressor,
+	}
+)
+
+// RegisterDecompressor makes the response helpers (Bytes, Text, JSON, XML,
+// ...) decode bodies with the Content-Encoding of c. gzip and deflate are
+// registered by default; httpx has no dependencies, so encodings such as br
+// are plugged in by the caller. Registering an encoding again replaces it.
+//
+// Example:
+//
+//	httpx.RegisterDecompressor(httpx.Compressor{
+//	    Encoding: "br",
+//	    NewReader: func(r io.Reader) (io.ReadCloser, error) {
+//	        return io.NopCloser(brotli.NewReader(r)), nil
+//	    },
+//	})
+func RegisterDecompressor(c Compressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+
+	decompressors[strings.ToLower(c.Encoding)] = c
+}
+
+// lookupDecompressor returns the registered decoder for encoding, if any.
+func lookupDecompressor(encoding string) (Compressor, bool) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+
+	c, ok := decompressors[encoding]
+	return c, ok && c.NewReader != nil
+}
+
+// decompressStream returns the response body wrapped in decoders for its
+// Content-Encoding. Bodies the transport already decompressed
+// (res.Uncompressed, header removed), empty bodies and bodies with an
+// unregistered encoding are returned unchanged. Stacked encodings
+// ("deflate, gzip") are undone in reverse order. Closing the result closes
+// the decoders but not res.Body.
+func decompressStream(res *http.Response) (io.ReadCloser, error) {
+	var r io.Reader = res.Body
+	var closers []io.Closer
+	var applied []string
+
+	closeAll := func() error {
+		var errs []error
+		for i := len(closers) - 1; i >= 0; i-- {
+			errs = append(errs, closers[i].Close())
+		}
+		return errors.Join(errs...)
+	}
+
+	if encodings := contentEncodings(res); !res.Uncompressed && len(encodings) > 0 {
+		// Empty bodies, e.g. of 204 responses, have nothing to decode
+		br := bufio.NewReader(res.Body)
+		if _, err := br.Peek(1); err == io.EOF {
+			encodings = nil
+		}
+		r = br
+
+		for i := len(encodings) - 1; i >= 0; i-- {
+			c, ok := lookupDecompressor(encodings[i])
+			if !ok {
+				break
+			}
+
+			dr, err := c.NewReader(r)
+			if err != nil {
+				closeAll()
+				return nil, fmt.Errorf("httpx: decoding %s response body: %w", encodings[i], err)
+			}
+
+			closers = append(closers, dr)
+			applied = append(applied, encodings[i])
+			r = dr
+		}
+	}
+
+	if len(applied) > 0 {
+		r = &decodeErrorReader{Reader: r, encoding: strings.Join(applied, ", ")}
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, closerFunc(closeAll)}, nil
+}
+
+// decodeErrorReader names the decoded encodings in read errors.
+type decodeErrorReader struct {
+	io.Reader
+	encoding string
+}
+
+func (r *decodeErrorReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("httpx: decoding %s response body: %w", r.encoding, err)
+	}
+	return n, err
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// contentEncodings lists the non-identity Content-Encoding tokens of res in
+// the order they were applied.
+func contentEncodings(res *http.Response) []string {
+	var encodings []string
+	for _, line := range res.Header.Values("Content-Encoding") {
+		for _, enc := range strings.Split(line, ",") {
+			if enc = strings.ToLower(strings.TrimSpace(enc)); enc != "" && enc != "identity" {
+				encodings = append(encodings, enc)
+			}
+		}
+	}
+	return encodings
+}
+
+// newDeflateReader reads HTTP deflate content, which is specified as zlib
+// but often sent as a raw deflate stream.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
@@ -0,0 +1,88 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// deflated returns n zero bytes compressed with raw deflate.
+func deflated(t *testing.T, n int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, n))
+	w.Close()
+	return buf.Bytes()
+}
+
+// encodedServer serves body with the given Content-Encoding and status.
+func encodedServer(t *testing.T, encoding string, status int, body []byte) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", encoding)
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDecompressDeflate(t *testing.T) {
+	srv := encodedServer(t, "deflate", http.StatusOK, deflated(t, 1000))
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, make([]byte, 1000)) {
+		t.Errorf("body = %d bytes, want 1000 zero bytes", len(body))
+	}
+}
+
+func TestDecompressBombIsLimited(t *testing.T) {
+	const limit = 64 << 10
+
+	bomb := deflated(t, 10<<20)
+	if len(bomb) > limit {
+		t.Fatalf("compressed body is %d bytes, want it below the limit", len(bomb))
+	}
+	srv := encodedServer(t, "deflate", http.StatusOK, bomb)
+
+	c := New(&Config{MaxResponseBytes: limit})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readBodyWithStatus(res)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+	}
+
+	var tooLarge *BodyTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("err = %T, want *BodyTooLargeError", err)
+	}
+	if tooLarge.Limit != limit || tooLarge.Read > limit+1 {
+		t.Errorf("err = %+v, want limit %d and at most one byte past it", tooLarge, limit)
+	}
+}
@@ -0,0 +1,108 @@
+package httpx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DownloadToFile streams the response body into the file at path and returns
+// the number of bytes written, without holding the body in memory. Bodies
+// with a registered Content-Encoding the transport left compressed are
+// decompressed on the fly.
+//
+// Non-2xx responses return an HttpError and no file is created. If the copy
+// fails, the partially written file is removed. The response body is always
+// closed.
+//
+// Example:
+//
+//	res, err := client.Get("https://cdn.com/releases/app.tar.gz")
+//	if err != nil {
+//	    return err
+//	}
+//	n, err := httpx.DownloadToFile(res, "/tmp/app.tar.gz")
+func DownloadToFile(res *http.Response, path string) (int64, error) {
+	if readOptionsFor(res).failed(res.StatusCode) {
+		_, err := readBodyWithStatus(res)
+		return 0, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		res.Body.Close()
+		return 0, fmt.Errorf("httpx: creating download file: %w", err)
+	}
+
+	n, err := Download(res, f, nil)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("httpx: downloading to %s: %w", path, closeErr)
+	}
+
+	if err != nil {
+		os.Remove(path)
+		return n, err
+	}
+
+	return n, nil
+}
+
+// Download streams the response body into w and returns the number of bytes
+// written. It decompresses like DownloadToFile and returns an HttpError for
+// non-2xx responses without writing anything. Afterwards the body is gone:
+// the other response helpers return ErrBodyAlreadyConsumed. If progress is non-nil, it is
+// called as the body arrives with the bytes received and Content-Length
+// (-1 when unknown); both count the body as sent by the server, i.e. before
+// decompression. The response body is always closed.
+//
+// Example:
+//
+//	n, err := httpx.Download(res, f, func(received, total int64) {
+//	    bar.Set(received, total)
+//	})
+func Download(res *http.Response, w io.Writer, progress ProgressFunc) (int64, error) {
+	if readOptionsFor(res).failed(res.StatusCode) {
+		_, err := readBodyWithStatus(res)
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	// A body already buffered by a helper is replayed from memory
+	buffered, ok, err := readOptionsFor(res).stream()
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		if progress != nil {
+			progress(int64(len(buffered)), int64(len(buffered)))
+		}
+		n, err := w.Write(buffered)
+		if err != nil {
+			return int64(n), fmt.Errorf("httpx: downloading response body: %w", err)
+		}
+		return int64(n), nil
+	}
+
+	if progress != nil {
+		total := res.ContentLength
+		if total < 0 || res.Uncompressed {
+			total = -1
+		}
+		res.Body = &progressReader{ReadCloser: res.Body, total: total, progress: progress}
+	}
+
+	body, err := decompressStream(res)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return n, fmt.Errorf("httpx: downloading response body: %w", err)
+	}
+
+	return n, nil
+}
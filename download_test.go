@@ -0,0 +1,205 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// chunkedServer streams chunks flushed one by one without Content-Length,
+// pausing between them. A negative count streams until the client goes
+// away; written reports the bytes the handler managed to send, and done is
+// closed when it returns.
+func chunkedServer(t *testing.T, chunk string, count int, pause time.Duration) (srv *httptest.Server, written *atomic.Int64, done chan struct{}) {
+	t.Helper()
+
+	written, done = &atomic.Int64{}, make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		for i := 0; count < 0 || i < count; i++ {
+			n, err := w.Write([]byte(chunk))
+			written.Add(int64(n))
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(pause)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, written, done
+}
+
+func TestDownloadProgressChunked(t *testing.T) {
+	const chunks = 5
+	chunk := strings.Repeat("c", 1000)
+	srv, _, _ := chunkedServer(t, chunk, chunks, 20*time.Millisecond)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ContentLength != -1 {
+		t.Fatalf("ContentLength = %d, want a chunked response", res.ContentLength)
+	}
+
+	var calls []int64
+	var buf bytes.Buffer
+	n, err := Download(res, &buf, func(received, total int64) {
+		if total != -1 {
+			t.Errorf("total = %d, want -1", total)
+		}
+		calls = append(calls, received)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// At least one callback per flushed chunk, with growing counts
+	if n != chunks*1000 || buf.Len() != chunks*1000 {
+		t.Errorf("downloaded %d bytes, buffer %d", n, buf.Len())
+	}
+	if len(calls) < chunks {
+		t.Errorf("%d callbacks for %d chunks", len(calls), chunks)
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i] <= calls[i-1] {
+			t.Errorf("received went from %d to %d", calls[i-1], calls[i])
+		}
+	}
+	if last := calls[len(calls)-1]; last != n {
+		t.Errorf("last callback reported %d of %d bytes", last, n)
+	}
+}
+
+func TestMaxResponseBytesChunked(t *testing.T) {
+	srv, written, done := chunkedServer(t, strings.Repeat("c", 4096), -1, time.Millisecond)
+
+	c := New(&Config{MaxResponseBytes: 64 << 10})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cap counts bytes as they arrive; there is no header to trust
+	_, err = c.(*client).Bytes(res)
+	var tooLarge *BodyTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 64<<10 {
+		t.Fatalf("err = %v, want the 64 KiB limit", err)
+	}
+
+	// The connection is closed instead of drained, so the server stops
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("server still streaming after %d bytes", written.Load())
+	}
+	if n := written.Load(); n > 16<<20 {
+		t.Errorf("server wrote %d bytes before the client hung up", n)
+	}
+}
+
+func TestDownloadToFile(t *testing.T) {
+	t.Run("writes the body", func(t *testing.T) {
+		chunk := strings.Repeat("c", 1000)
+		srv, _, _ := chunkedServer(t, chunk, 5, 0)
+		path := filepath.Join(t.TempDir(), "body")
+
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := DownloadToFile(res, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 5000 || string(got) != strings.Repeat(chunk, 5) {
+			t.Errorf("wrote %d bytes, file has %d", n, len(got))
+		}
+	})
+
+	t.Run("decompresses", func(t *testing.T) {
+		srv := encodedServer(t, "deflate", http.StatusOK, deflated(t, 1000))
+		path := filepath.Join(t.TempDir(), "body")
+
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DownloadToFile(res, path); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(path); !bytes.Equal(got, make([]byte, 1000)) {
+			t.Errorf("file has %d bytes, want 1000 decompressed zeros", len(got))
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		srv := encodedServer(t, "identity", http.StatusNotFound, []byte("missing"))
+		path := filepath.Join(t.TempDir(), "body")
+
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var httpErr *HttpError
+		if _, err := DownloadToFile(res, path); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("err = %v, want a 404 HttpError", err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("file created for an error response: %v", err)
+		}
+	})
+
+	t.Run("failed copy", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "10000")
+			w.Write([]byte(strings.Repeat("c", 1000)))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler) // cut the body short
+		}))
+		defer srv.Close()
+		path := filepath.Join(t.TempDir(), "body")
+
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DownloadToFile(res, path); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("partial file left behind: %v", err)
+		}
+	})
+}
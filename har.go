@@ -0,0 +1,416 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HARConfig enables recording of requests and responses into an HTTP
+// Archive (HAR 1.2), written with WriteHAR. The archive opens in browser
+// devtools and most debugging proxies.
+//
+// An entry is recorded once its response body is closed, or when the
+// request fails. Bodies larger than MaxBodySize are truncated, and the
+// values of RedactHeaders are replaced in both headers and cookies.
+type HARConfig struct {
+	// MaxBodySize caps the bytes stored per request and response body.
+	// A value of 0 uses 64 KB; a negative value omits bodies entirely.
+	MaxBodySize int
+
+	// MaxEntries caps the archive size; the oldest entries are dropped first.
+	// A value of 0 uses 1000.
+	MaxEntries int
+
+	// RedactHeaders lists headers whose values are replaced by "[REDACTED]".
+	// Nil uses Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+}
+
+// harRedacted replaces the values of redacted headers and cookies.
+const harRedacted = "[REDACTED]"
+
+// harRecorder collects HAR entries. Concurrent requests append under mu.
+type harRecorder struct {
+	cfg    HARConfig
+	redact map[string]bool
+
+	mu      sync.Mutex
+	entries []*harEntry
+}
+
+// newHARRecorder returns a recorder applying the defaults of cfg.
+func newHARRecorder(cfg HARConfig) *harRecorder {
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 64 << 10
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 1000
+	}
+
+	return &harRecorder{cfg: cfg, redact: redactSet(cfg.RedactHeaders)}
+}
+
+// add appends a finished entry, dropping the oldest beyond MaxEntries.
+func (h *harRecorder) add(e *harEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, e)
+	if over := len(h.entries) - h.cfg.MaxEntries; over > 0 {
+		h.entries = append(h.entries[:0:0], h.entries[over:]...)
+	}
+}
+
+// WriteHAR writes all requests recorded so far as a HAR 1.2 document to w.
+// Entries stay recorded, so repeated calls produce growing archives. It
+// returns an error when Config.HAR is not set.
+//
+// Example:
+//
+//	f, _ := os.Create("flow.har")
+//	defer f.Close()
+//	err := client.WriteHAR(f)
+func (c *client) WriteHAR(w io.Writer) error {
+	if c.har == nil {
+		return errors.New("httpx: HAR recording is disabled (set Config.HAR)")
+	}
+
+	c.har.mu.Lock()
+	entries := make([]*harEntry, len(c.har.entries))
+	copy(entries, c.har.entries)
+	c.har.mu.Unlock()
+
+	// Entries are recorded on completion but listed in start order
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].started.Before(entries[j].started)
+	})
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "httpx", Version: "1"},
+		Entries: entries,
+	}}
+	if doc.Log.Entries == nil {
+		doc.Log.Entries = []*harEntry{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// harDocument and the types below mirror the HAR 1.2 JSON schema.
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	started time.Time
+
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           harCache    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// harCache reports the cache status, taken from the Cache-Status or X-Cache
+// response header when the server sends one.
+type harCache struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// begin starts an entry for req. body holds the encoded request body, or nil
+// when the body is streamed or absent.
+func (h *harRecorder) begin(req *http.Request, body []byte, streamed bool) *harEntry {
+	started := time.Now()
+
+	e := &harEntry{
+		started:         started,
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     h.cookies(req.Cookies()),
+			Headers:     h.headers(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			e.Request.QueryString = append(e.Request.QueryString, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.Slice(e.Request.QueryString, func(i, j int) bool {
+		return e.Request.QueryString[i].Name < e.Request.QueryString[j].Name
+	})
+
+	switch {
+	case body != nil:
+		text, encoding, comment := h.body(body, int64(len(body)))
+		e.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+			Comment:  comment,
+		}
+		e.Request.BodySize = int64(len(body))
+	case streamed:
+		e.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Comment:  "streamed body not captured",
+		}
+	}
+
+	return e
+}
+
+// fail records a request that did not produce a response.
+func (h *harRecorder) fail(e *harEntry, p *phaseTracker, err error) {
+	e.Error = err.Error()
+	e.finishTimings(p)
+	h.add(e)
+}
+
+// finish records a completed response whose body was read into captured.
+// size is the total number of body bytes read, including those beyond the
+// capture limit.
+func (h *harRecorder) finish(e *harEntry, res *http.Response, p *phaseTracker, captured []byte, size int64) {
+	e.Response.Status = res.StatusCode
+	e.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(res.Status, strconv.Itoa(res.StatusCode)))
+	e.Response.HTTPVersion = res.Proto
+	e.Response.Cookies = h.cookies(res.Cookies())
+	e.Response.Headers = h.headers(res.Header)
+	e.Response.RedirectURL = res.Header.Get("Location")
+	e.Response.BodySize = size
+
+	text, encoding, comment := h.body(captured, size)
+	e.Response.Content = harContent{
+		Size:     size,
+		MimeType: res.Header.Get("Content-Type"),
+		Text:     text,
+		Encoding: encoding,
+		Comment:  comment,
+	}
+
+	if status := res.Header.Get("Cache-Status"); status != "" {
+		e.Cache.Comment = status
+	} else if status := res.Header.Get("X-Cache"); status != "" {
+		e.Cache.Comment = status
+	}
+
+	e.finishTimings(p)
+	h.add(e)
+}
+
+// finishTimings maps the phases of p onto HAR timings in milliseconds.
+// Phases repeated by retries are summed; everything after the first
+// response byte counts as receive.
+func (e *harEntry) finishTimings(p *phaseTracker) {
+	total := time.Since(e.started)
+	_, completed := p.snapshot()
+
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+
+	var t harTimings
+	var accounted time.Duration
+	for _, phase := range completed {
+		accounted += phase.Duration
+		d := ms(phase.Duration)
+
+		switch phase.Phase {
+		case phaseQueued, phaseRetryBackoff:
+			t.Blocked += d
+		case phaseDNS:
+			t.DNS += d
+		case phaseDialing:
+			t.Connect += d
+		case phaseTLS:
+			t.SSL += d
+			t.Connect += d // HAR counts ssl as part of connect
+		case phaseWriting:
+			t.Send += d
+		case phaseWaitingHeaders:
+			t.Wait += d
+		default:
+			t.Receive += d
+		}
+	}
+	t.Receive += ms(total - accounted)
+
+	e.Time = ms(total)
+	e.Timings = t
+}
+
+// headers converts h into HAR name/value pairs with redaction applied.
+func (h *harRecorder) headers(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, v := range values {
+			if h.redact[http.CanonicalHeaderKey(name)] {
+				v = harRedacted
+			}
+			pairs = append(pairs, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// cookies converts cookies into HAR pairs, redacting values when cookie
+// headers are redacted.
+func (h *harRecorder) cookies(cookies []*http.Cookie) []harNameValue {
+	redact := h.redact["Cookie"] || h.redact["Set-Cookie"]
+
+	pairs := []harNameValue{}
+	for _, c := range cookies {
+		v := c.Value
+		if redact {
+			v = harRedacted
+		}
+		pairs = append(pairs, harNameValue{Name: c.Name, Value: v})
+	}
+	return pairs
+}
+
+// body renders a captured body as HAR text. Binary bodies are base64
+// encoded; bodies cut off at MaxBodySize carry a comment.
+func (h *harRecorder) body(b []byte, size int64) (text, encoding, comment string) {
+	if h.cfg.MaxBodySize < 0 {
+		return "", "", "body omitted"
+	}
+
+	if len(b) > h.cfg.MaxBodySize {
+		b = b[:h.cfg.MaxBodySize]
+	}
+	if int64(len(b)) < size {
+		comment = "body truncated"
+	}
+
+	if utf8.Valid(b) {
+		return string(b), "", comment
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64", comment
+}
+
+// harBody captures the response body for the HAR entry while it is read
+// and records the entry on Close.
+type harBody struct {
+	io.ReadCloser
+	recorder *harRecorder
+	entry    *harEntry
+	res      *http.Response
+	phases   *phaseTracker
+
+	captured []byte
+	size     int64
+	once     sync.Once
+}
+
+// Read implements io.Reader.
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	if room := b.recorder.cfg.MaxBodySize - len(b.captured); room > 0 && n > 0 {
+		b.captured = append(b.captured, p[:min(n, room)]...)
+	}
+
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.recorder.finish(b.entry, b.res, b.phases, b.captured, b.size)
+	})
+	return err
+}
@@ -0,0 +1,110 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteHARConcurrent(t *testing.T) {
+	const requests = 20
+	response := strings.Repeat("r", 1024)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		time.Sleep(2 * time.Millisecond) // a measurable wait
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	c := New(&Config{HAR: &HARConfig{MaxBodySize: 256}})
+	defer c.Close(context.Background())
+
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := c.Post(srv.URL+"/items?page=1",
+				WithBody(strings.Repeat("q", 2048)),
+				WithBearerToken("s3cret"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := readBodyWithStatus(res); err != nil {
+				t.Error(err)
+			}
+
+			// Archives written while requests finish must not race
+			c.WriteHAR(io.Discard)
+		}()
+	}
+	wg.Wait()
+
+	var buf bytes.Buffer
+	if err := c.WriteHAR(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc harDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("archive is not valid JSON: %v", err)
+	}
+
+	if doc.Log.Version != "1.2" {
+		t.Errorf("version = %q, want 1.2", doc.Log.Version)
+	}
+	if len(doc.Log.Entries) != requests {
+		t.Fatalf("%d entries, want %d", len(doc.Log.Entries), requests)
+	}
+
+	for i, e := range doc.Log.Entries {
+		var authorization []string
+		for _, h := range e.Request.Headers {
+			if h.Name == "Authorization" {
+				authorization = append(authorization, h.Value)
+			}
+		}
+		if len(authorization) != 1 || authorization[0] != harRedacted {
+			t.Errorf("entry %d: Authorization = %q, want it redacted", i, authorization)
+		}
+		if e.Request.PostData == nil || len(e.Request.PostData.Text) != 256 || e.Request.PostData.Comment != "body truncated" {
+			t.Errorf("entry %d: request body not capped: %+v", i, e.Request.PostData)
+		}
+		if e.Request.BodySize != 2048 {
+			t.Errorf("entry %d: request bodySize = %d, want 2048", i, e.Request.BodySize)
+		}
+		if content := e.Response.Content; len(content.Text) != 256 || content.Size != 1024 || content.Comment != "body truncated" {
+			t.Errorf("entry %d: response body not capped: size %d, %d bytes captured, comment %q",
+				i, content.Size, len(content.Text), content.Comment)
+		}
+		if e.Response.Status != http.StatusOK {
+			t.Errorf("entry %d: status %d", i, e.Response.Status)
+		}
+
+		timings := e.Timings
+		if e.Time <= 0 || timings.Wait <= 0 {
+			t.Errorf("entry %d: time %v, wait %v, want both positive", i, e.Time, timings.Wait)
+		}
+		for name, d := range map[string]float64{
+			"blocked": timings.Blocked, "dns": timings.DNS, "connect": timings.Connect,
+			"ssl": timings.SSL, "send": timings.Send, "receive": timings.Receive,
+		} {
+			if d < 0 {
+				t.Errorf("entry %d: %s timing %v is negative", i, name, d)
+			}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, e.StartedDateTime); err != nil {
+			t.Errorf("entry %d: startedDateTime %q: %v", i, e.StartedDateTime, err)
+		}
+	}
+}
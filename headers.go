@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// SetHeaders sets the given global headers, replacing existing values of the
+// same keys. Headers not mentioned in h are kept. It is safe to call while
+// requests are in flight; requests already started keep the headers they
+// were built with.
+//
+// Example:
+//
+//	client.SetHeaders(http.Header{
+//	    "Authorization": []string{"Bearer " + token},
+//	    "X-Tenant":      []string{"acme"},
+//	})
+func (c *client) SetHeaders(h http.Header) {
+	c.headersMu.Lock()
+	defer c.headersMu.Unlock()
+
+	headers := c.Headers.Clone()
+	for key, values := range h {
+		headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	c.Headers = headers
+}
+
+// SetHeader sets a single global header, replacing any existing value.
+//
+// Example:
+//
+//	client.SetHeader("Authorization", "Bearer "+token)
+func (c *client) SetHeader(key, value string) {
+	c.headersMu.Lock()
+	defer c.headersMu.Unlock()
+
+	headers := c.Headers.Clone()
+	headers.Set(key, value)
+	c.Headers = headers
+}
+
+// RemoveHeader deletes a global header.
+func (c *client) RemoveHeader(key string) {
+	c.headersMu.Lock()
+	defer c.headersMu.Unlock()
+
+	headers := c.Headers.Clone()
+	headers.Del(key)
+	c.Headers = headers
+}
+
+// globalHeaders returns the current global headers. The map is replaced on
+// every change and never mutated afterwards, so callers may read it without
+// holding the lock.
+func (c *client) globalHeaders() http.Header {
+	c.headersMu.RLock()
+	defer c.headersMu.RUnlock()
+
+	return c.Headers
+}
+
+// BasicAuth holds the credentials for HTTP Basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// basicAuthorization encodes user and pass as a Basic Authorization value.
+func basicAuthorization(user, pass string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+}
+
+// defaultAuthorization returns the Authorization value for the client
+// credentials in the Config, or "" when none are set.
+func (c *client) defaultAuthorization() string {
+	switch {
+	case c.BasicAuth != nil:
+		return basicAuthorization(c.BasicAuth.Username, c.BasicAuth.Password)
+	case c.BearerToken != "":
+		return "Bearer " + c.BearerToken
+	}
+	return ""
+}
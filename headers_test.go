@@ -0,0 +1,248 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// authServer echoes the Authorization header and the Basic credentials the
+// server parsed from it.
+func authServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		if user, pass, ok := r.BasicAuth(); ok {
+			w.Header().Set("X-User", user)
+			w.Header().Set("X-Pass", pass)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithBasicAuth(t *testing.T) {
+	srv := authServer(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	// Colons in the password survive; only the first one separates the user
+	res, err := c.Get(srv.URL, WithBasicAuth("Aladdin", "open:sesame ü"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Authorization"); got != "Basic QWxhZGRpbjpvcGVuOnNlc2FtZSDDvA==" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if user, pass := res.Header.Get("X-User"), res.Header.Get("X-Pass"); user != "Aladdin" || pass != "open:sesame ü" {
+		t.Errorf("server parsed %q:%q", user, pass)
+	}
+}
+
+func TestWithBearerToken(t *testing.T) {
+	srv := authServer(t)
+
+	c := New(&Config{BasicAuth: &BasicAuth{"cfg", "pw"}, Headers: http.Header{"Authorization": {"Token global"}}})
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"overrides client credentials", []Option{WithBearerToken("t0k3n")}, "Bearer t0k3n"},
+		{"last option wins", []Option{WithBasicAuth("req", "pw"), WithBearerToken("t0k3n")}, "Bearer t0k3n"},
+		{"request header wins", []Option{WithBearerToken("t0k3n"), WithHeaders(http.Header{"Authorization": {"Token request"}})}, "Token request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Authorization"); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationPrecedence(t *testing.T) {
+	srv := authServer(t)
+
+	global := http.Header{"Authorization": {"Token global"}}
+	tests := []struct {
+		name   string
+		config *Config
+		opts   []Option
+		want   string
+	}{
+		{"config basic auth", &Config{BasicAuth: &BasicAuth{"cfg", "pw"}}, nil, basicAuthorization("cfg", "pw")},
+		{"config bearer token", &Config{BearerToken: "t0k3n"}, nil, "Bearer t0k3n"},
+		{"basic auth before bearer token", &Config{BasicAuth: &BasicAuth{"cfg", "pw"}, BearerToken: "t0k3n"}, nil, basicAuthorization("cfg", "pw")},
+		{"global header before config credentials", &Config{Headers: global, BearerToken: "t0k3n"}, nil, "Token global"},
+		{"request basic auth before global header", &Config{Headers: global}, []Option{WithBasicAuth("req", "pw")}, basicAuthorization("req", "pw")},
+		{"request basic auth before config credentials", &Config{BasicAuth: &BasicAuth{"cfg", "pw"}}, []Option{WithBasicAuth("req", "pw")}, basicAuthorization("req", "pw")},
+		{
+			"request header before request basic auth",
+			&Config{Headers: global},
+			[]Option{WithBasicAuth("req", "pw"), WithHeaders(http.Header{"Authorization": {"Token request"}})},
+			"Token request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.config)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Authorization"); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetHeadersWhileInFlight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Headers: http.Header{"Authorization": {"Bearer 0"}, "X-Tenant": {"0"}}})
+	defer c.Close(context.Background())
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	// Writers replace both headers together and toggle an unrelated one
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				n := strconv.Itoa(w*1000 + i)
+				c.SetHeaders(http.Header{"Authorization": {"Bearer " + n}, "x-tenant": {n}})
+				c.SetHeader("X-Debug", n)
+				c.RemoveHeader("X-Debug")
+			}
+		}()
+	}
+
+	// Every request sees one consistent SetHeaders call
+	var readers sync.WaitGroup
+	for range 8 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for range 10 {
+				res, err := c.Get(srv.URL)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				res.Body.Close()
+
+				auth, tenant := res.Header.Get("X-Authorization"), res.Header.Get("X-Tenant")
+				if auth != "Bearer "+tenant {
+					t.Errorf("request mixed headers: Authorization %q with X-Tenant %q", auth, tenant)
+				}
+			}
+		}()
+	}
+	readers.Wait()
+	close(stop)
+	wg.Wait()
+}
+
+func TestSetHeadersKeepsStartedRequests(t *testing.T) {
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Headers: http.Header{"X-Tenant": {"before"}}})
+	defer c.Close(context.Background())
+
+	done := make(chan *http.Response)
+	go func() {
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- res
+	}()
+
+	<-arrived
+	c.SetHeaders(http.Header{"X-Tenant": {"after"}})
+	close(release)
+
+	if res := <-done; res != nil {
+		res.Body.Close()
+		if got := res.Header.Get("X-Tenant"); got != "before" {
+			t.Errorf("started request sent X-Tenant %q, want %q", got, "before")
+		}
+	}
+	if got := c.(*client).globalHeaders().Get("X-Tenant"); got != "after" {
+		t.Errorf("global X-Tenant = %q, want %q", got, "after")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-User-Agent", r.UserAgent())
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		config *Config
+		opts   []Option
+		want   string
+	}{
+		{"Go default", nil, nil, "Go-http-client/1.1"},
+		{"Config.UserAgent", &Config{UserAgent: "app/1.0"}, nil, "app/1.0"},
+		{"global header wins", &Config{UserAgent: "app/1.0", Headers: http.Header{"User-Agent": {"global/2.0"}}}, nil, "global/2.0"},
+		{"request header wins", &Config{UserAgent: "app/1.0"}, []Option{WithHeaders(http.Header{"User-Agent": {"request/3.0"}})}, "request/3.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.config)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-User-Agent"); got != tt.want {
+				t.Errorf("User-Agent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
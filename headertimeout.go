@@ -0,0 +1,59 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// ErrResponseHeaderTimeout is returned when a request configured with
+// WithResponseHeaderTimeout did not receive response headers in time. It is
+// distinct from total-timeout failures, which report DeadlineError.
+var ErrResponseHeaderTimeout = errors.New("httpx: timeout awaiting response headers")
+
+// headerTimer cancels a request when its response headers do not start to
+// arrive within the configured duration. Once the first response byte is
+// received the timer is disarmed, leaving the body read unconstrained.
+type headerTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	ctx     context.Context
+}
+
+// watchHeaders derives a context from ctx that is cancelled with
+// ErrResponseHeaderTimeout after d unless disarmed. The returned cancel func
+// releases the timer.
+func watchHeaders(ctx context.Context, d time.Duration) (*headerTimer, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	h := &headerTimer{
+		timeout: d,
+		timer:   time.AfterFunc(d, func() { cancel(ErrResponseHeaderTimeout) }),
+		ctx:     ctx,
+	}
+
+	return h, ctx, func() {
+		h.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// trace disarms the timer as soon as the first response byte arrives.
+func (h *headerTimer) trace(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { h.timer.Stop() },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// wrap converts a failure caused by the timer into an
+// ErrResponseHeaderTimeout error. Other errors are returned unchanged.
+func (h *headerTimer) wrap(err error) error {
+	if err != nil && context.Cause(h.ctx) == ErrResponseHeaderTimeout {
+		return fmt.Errorf("%w after %s", ErrResponseHeaderTimeout, h.timeout)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	t.Run("headers late", func(t *testing.T) {
+		srv := slowServer(t, time.Second, 0)
+
+		_, err := c.Get(srv.URL, WithResponseHeaderTimeout(50*time.Millisecond))
+		if !errors.Is(err, ErrResponseHeaderTimeout) {
+			t.Fatalf("err = %v, want ErrResponseHeaderTimeout", err)
+		}
+		var de *DeadlineError
+		if errors.As(err, &de) {
+			t.Errorf("header timeout reported as total timeout: %v", err)
+		}
+	})
+
+	t.Run("total timeout stays distinct", func(t *testing.T) {
+		srv := slowServer(t, time.Second, 0)
+
+		_, err := c.Get(srv.URL, WithTimeout(50*time.Millisecond))
+		var de *DeadlineError
+		if !errors.As(err, &de) {
+			t.Fatalf("err = %v, want *DeadlineError", err)
+		}
+		if errors.Is(err, ErrResponseHeaderTimeout) {
+			t.Errorf("total timeout reported as header timeout: %v", err)
+		}
+	})
+
+	t.Run("slow body", func(t *testing.T) {
+		// Headers arrive at once, the body takes four times the header timeout
+		srv := slowServer(t, 0, 200*time.Millisecond)
+
+		res, err := c.Get(srv.URL, WithResponseHeaderTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, err := readBodyWithStatus(res)
+		if err != nil {
+			t.Fatalf("body read cut off: %v", err)
+		}
+		if string(body) != "firstsecond" {
+			t.Errorf("body = %q, want %q", body, "firstsecond")
+		}
+	})
+}
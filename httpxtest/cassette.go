@@ -0,0 +1,329 @@
+package httpxtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Body encodings used in cassettes.
+const (
+	BodyPlain      = ""            // UTF-8 text stored verbatim
+	BodyBase64     = "base64"      // binary data
+	BodyGzipBase64 = "gzip+base64" // gzip-compressed data, base64 encoded
+)
+
+// Body is a request or response body stored in a cassette.
+type Body struct {
+	Encoding string `json:"encoding,omitempty"` // One of the Body* encodings
+	Data     string `json:"data"`               // Encoded body
+}
+
+// RecordedRequest is the request half of an Interaction.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body"`
+}
+
+// RecordedResponse is the response half of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body"`
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is an ordered list of recorded interactions. It is stored as JSON
+// or, for ".yaml" and ".yml" paths, as YAML, either optionally
+// gzip-compressed (".json.gz", ".yaml.gz").
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// encodeBody stores b in the most compact faithful representation. Bodies
+// larger than compressAbove (when > 0) are gzip-compressed.
+func encodeBody(b []byte, compressAbove int) (Body, error) {
+	if compressAbove > 0 && len(b) > compressAbove {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(b); err != nil {
+			return Body{}, err
+		}
+		if err := zw.Close(); err != nil {
+			return Body{}, err
+		}
+		return Body{Encoding: BodyGzipBase64, Data: base64.StdEncoding.EncodeToString(buf.Bytes())}, nil
+	}
+
+	if utf8.Valid(b) {
+		return Body{Data: string(b)}, nil
+	}
+
+	return Body{Encoding: BodyBase64, Data: base64.StdEncoding.EncodeToString(b)}, nil
+}
+
+// Bytes decodes the stored body back into its original bytes.
+func (b Body) Bytes() ([]byte, error) {
+	switch b.Encoding {
+	case BodyPlain:
+		return []byte(b.Data), nil
+
+	case BodyBase64:
+		return base64.StdEncoding.DecodeString(b.Data)
+
+	case BodyGzipBase64:
+		raw, err := base64.StdEncoding.DecodeString(b.Data)
+		if err != nil {
+			return nil, err
+		}
+		return gunzip(raw)
+
+	default:
+		return nil, fmt.Errorf("httpxtest: unknown body encoding %q", b.Encoding)
+	}
+}
+
+// gunzip decompresses gzip data.
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+// isGzip reports whether data starts with the gzip magic number.
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// LoadCassette reads a cassette file. Paths ending in ".yaml" or ".yml"
+// (before any ".gz") are decoded as YAML, all others as JSON. Compressed
+// cassettes (".json.gz", ".yaml.gz", or any file starting with the gzip magic
+// number) are decompressed transparently.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if isGzip(data) {
+		if data, err = gunzip(data); err != nil {
+			return nil, fmt.Errorf("httpxtest: decompressing cassette %s: %w", path, err)
+		}
+	}
+
+	var c Cassette
+	if isYAMLPath(path) {
+		err = unmarshalYAML(data, &c)
+	} else {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("httpxtest: decoding cassette %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// Save writes the cassette to path and returns the path actually written.
+// The format follows the extension as in LoadCassette. Paths ending in ".gz"
+// are always gzip-compressed. Otherwise, when compressAbove is > 0 and the
+// encoded cassette is larger, it is compressed and ".gz" is appended to the
+// path.
+func (c *Cassette) Save(path string, compressAbove int) (string, error) {
+	var data []byte
+	if isYAMLPath(path) {
+		data = c.marshalYAML()
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(c, "", "  "); err != nil {
+			return "", err
+		}
+	}
+
+	compress := strings.HasSuffix(path, ".gz")
+	if !compress && compressAbove > 0 && len(data) > compressAbove {
+		compress = true
+		path += ".gz"
+	}
+
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return "", err
+		}
+		if err := zw.Close(); err != nil {
+			return "", err
+		}
+		data = buf.Bytes()
+	}
+
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// Recorder is an http.Handler that forwards every request to the real
+// upstream and records the interaction. Combine it with httpx.NewMockClient:
+//
+//	rec := &httpxtest.Recorder{CompressBodiesAbove: 4096}
+//	client := httpx.NewMockClient(rec, nil)
+//	// ... exercise client ...
+//	rec.Cassette().Save("testdata/users.json", 1<<20)
+type Recorder struct {
+	// Transport sends the forwarded requests. Nil uses http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// CompressBodiesAbove stores bodies larger than this many bytes
+	// gzip-compressed. A value of 0 never compresses bodies.
+	CompressBodiesAbove int
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	reqBody, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, "httpxtest: reading request body: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	out.ContentLength = int64(len(reqBody))
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	res, err := transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "httpxtest: forwarding request: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		http.Error(w, "httpxtest: reading response body: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	storedReq, err := encodeBody(reqBody, r.CompressBodiesAbove)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	storedRes, err := encodeBody(resBody, r.CompressBodiesAbove)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+			Body:    storedReq,
+		},
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Headers:    res.Header.Clone(),
+			Body:       storedRes,
+		},
+	})
+	r.mu.Unlock()
+
+	writeResponse(w, res.StatusCode, res.Header, resBody)
+}
+
+// Cassette returns a copy of everything recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := &Cassette{Interactions: make([]Interaction, len(r.cassette.Interactions))}
+	copy(c.Interactions, r.cassette.Interactions)
+	return c
+}
+
+// Replayer is an http.Handler serving recorded interactions. Each request is
+// matched by method and URL against the first interaction not served yet.
+// Stored bodies are decompressed transparently.
+//
+//	cassette, _ := httpxtest.LoadCassette("testdata/users.json.gz")
+//	client := httpx.NewMockClient(httpxtest.NewReplayer(cassette), nil)
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	served   []bool
+}
+
+// NewReplayer returns a Replayer serving the interactions of c.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{cassette: c, served: make([]bool, len(c.Interactions))}
+}
+
+// ServeHTTP implements http.Handler. Unmatched requests are answered with
+// 501 Not Implemented.
+func (p *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	var match *Interaction
+	for i := range p.cassette.Interactions {
+		in := &p.cassette.Interactions[i]
+		if !p.served[i] && in.Request.Method == req.Method && in.Request.URL == req.URL.String() {
+			p.served[i] = true
+			match = in
+			break
+		}
+	}
+	p.mu.Unlock()
+
+	if match == nil {
+		http.Error(w, fmt.Sprintf("httpxtest: no recorded interaction for %s %s", req.Method, req.URL), http.StatusNotImplemented)
+		return
+	}
+
+	body, err := match.Response.Body.Bytes()
+	if err != nil {
+		http.Error(w, "httpxtest: decoding recorded body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, match.Response.StatusCode, match.Response.Headers, body)
+}
+
+// writeResponse copies status, headers and body to w.
+func writeResponse(w http.ResponseWriter, status int, header http.Header, body []byte) {
+	for key, values := range header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,168 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exchange sends a request with body through h and returns the response.
+func exchange(t *testing.T, h http.Handler, method, url string, body []byte) *http.Response {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, bytes.NewReader(body)))
+	return w.Result()
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	binary := []byte{0x00, 0xff, 0xfe, 0x1f, 0x8b, '\n', '"', '\\'}
+	large := bytes.Repeat([]byte("compressible "), 100)
+	text := []byte("héllo \"quoted\"\n\ttab: # not a comment\r\n")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header()["X-Multi"] = []string{"a", "b: c"}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	rec := &Recorder{CompressBodiesAbove: 512}
+	bodies := map[string][]byte{"/binary": binary, "/large": large, "/text": text, "/empty": nil}
+	paths := []string{"/binary", "/large", "/text", "/empty"}
+	for _, path := range paths {
+		exchange(t, rec, http.MethodPost, srv.URL+path, bodies[path]).Body.Close()
+	}
+
+	recorded := rec.Cassette()
+	if got := recorded.Interactions[0].Response.Body.Encoding; got != BodyBase64 {
+		t.Fatalf("binary body stored as %q", got)
+	}
+	if got := recorded.Interactions[1].Response.Body.Encoding; got != BodyGzipBase64 {
+		t.Fatalf("large body stored as %q", got)
+	}
+
+	for _, name := range []string{"c.json", "c.json.gz", "c.yaml", "c.yml", "c.yaml.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path, err := recorded.Save(filepath.Join(t.TempDir(), name), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := LoadCassette(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Empty and nil header maps both encode as absent
+			got, _ := json.Marshal(loaded)
+			want, _ := json.Marshal(recorded)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("loaded cassette differs:\n got %s\nwant %s", got, want)
+			}
+
+			replayer := NewReplayer(loaded)
+			for _, path := range paths {
+				res := exchange(t, replayer, http.MethodPost, srv.URL+path, nil)
+				got, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+
+				if res.StatusCode != http.StatusAccepted || !bytes.Equal(got, bodies[path]) {
+					t.Errorf("%s: replayed %d %q, want %d %q", path, res.StatusCode, got, http.StatusAccepted, bodies[path])
+				}
+				if got := res.Header["X-Multi"]; !reflect.DeepEqual(got, []string{"a", "b: c"}) {
+					t.Errorf("%s: X-Multi = %q", path, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveCompressesAboveThreshold(t *testing.T) {
+	c := &Cassette{Interactions: []Interaction{{
+		Request:  RecordedRequest{Method: http.MethodGet, URL: "http://example.com/"},
+		Response: RecordedResponse{StatusCode: http.StatusOK, Body: Body{Data: strings.Repeat("x", 100)}},
+	}}}
+
+	for _, name := range []string{"c.json", "c.yaml"} {
+		path, err := c.Save(filepath.Join(t.TempDir(), name), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(path, name+".gz") {
+			t.Errorf("saved to %s, want a .gz path", path)
+		}
+		if _, err := LoadCassette(path); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestLoadHandWrittenYAML(t *testing.T) {
+	const doc = `---
+# Edited by hand
+interactions:
+- request:
+    method: GET
+    url: 'http://example.com/it''s'
+    headers:
+      Accept: [application/json, "text/plain"]
+    body:
+      data: ""
+  response:
+    status_code: 200   # OK
+    headers:
+      Content-Type:
+      - application/json
+    body:
+      data: |
+        {"id": 1}
+- request:
+    method: POST
+    url: "http://example.com/\u00e9"
+  response:
+    status_code: 204
+    body:
+      encoding: base64
+      data: AP8=
+`
+	path := filepath.Join(t.TempDir(), "c.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Cassette{Interactions: []Interaction{
+		{
+			Request: RecordedRequest{
+				Method:  http.MethodGet,
+				URL:     "http://example.com/it's",
+				Headers: http.Header{"Accept": {"application/json", "text/plain"}},
+			},
+			Response: RecordedResponse{
+				StatusCode: http.StatusOK,
+				Headers:    http.Header{"Content-Type": {"application/json"}},
+				Body:       Body{Data: "{\"id\": 1}\n"},
+			},
+		},
+		{
+			Request:  RecordedRequest{Method: http.MethodPost, URL: "http://example.com/é"},
+			Response: RecordedResponse{StatusCode: http.StatusNoContent, Body: Body{Encoding: BodyBase64, Data: "AP8="}},
+		},
+	}}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("loaded\n %+v\nwant\n %+v", c, want)
+	}
+}
@@ -0,0 +1,323 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yousef-muc/httpx"
+)
+
+// WireRequest is a request as received by the server: what the client put
+// on the wire.
+type WireRequest struct {
+	Method     string
+	RequestURI string      // path and query string, e.g. "/items?a=1"
+	Headers    http.Header // for Scenario.Want: only these are compared
+	Body       []byte
+}
+
+// Scenario is a canonical request of the conformance suite together with
+// the exact wire request it must produce.
+type Scenario struct {
+	Name    string
+	Method  string
+	Path    string                // appended to the base URL, may include a query
+	Options func() []httpx.Option // fresh per run, since bodies may be readers
+	Want    WireRequest
+}
+
+// conformanceItem is the struct body of the JSON and XML scenarios.
+type conformanceItem struct {
+	XMLName xml.Name `json:"-" xml:"item"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+// Scenarios are the golden fixtures run by RunConformance. Forks may append
+// their own before running the suite.
+var Scenarios = []Scenario{
+	{
+		Name:   "GET with sorted params",
+		Method: http.MethodGet,
+		Path:   "/items",
+		Options: func() []httpx.Option {
+			return []httpx.Option{
+				httpx.WithParams(map[string]string{"b": "2", "a": "1"}),
+			}
+		},
+		Want: WireRequest{Method: http.MethodGet, RequestURI: "/items?a=1&b=2"},
+	},
+	{
+		Name:    "POST JSON struct",
+		Method:  http.MethodPost,
+		Path:    "/items",
+		Options: func() []httpx.Option { return []httpx.Option{httpx.WithBody(conformanceItem{Name: "Ada"})} },
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/items",
+			Headers:    http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"name":"Ada"}`),
+		},
+	},
+	{
+		Name:    "POST string as text",
+		Method:  http.MethodPost,
+		Path:    "/notes",
+		Options: func() []httpx.Option { return []httpx.Option{httpx.WithBody("hello")} },
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/notes",
+			Headers:    http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:       []byte("hello"),
+		},
+	},
+	{
+		Name:    "POST bytes as octet-stream",
+		Method:  http.MethodPost,
+		Path:    "/blobs",
+		Options: func() []httpx.Option { return []httpx.Option{httpx.WithBody([]byte{0x00, 0xff})} },
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/blobs",
+			Headers:    http.Header{"Content-Type": {"application/octet-stream"}},
+			Body:       []byte{0x00, 0xff},
+		},
+	},
+	{
+		Name:   "POST url.Values as form",
+		Method: http.MethodPost,
+		Path:   "/login",
+		Options: func() []httpx.Option {
+			return []httpx.Option{httpx.WithBody(url.Values{"user": {"ada"}, "pass": {"s3cret"}})}
+		},
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/login",
+			Headers:    http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			Body:       []byte("pass=s3cret&user=ada"),
+		},
+	},
+	{
+		Name:   "POST XML struct",
+		Method: http.MethodPost,
+		Path:   "/items",
+		Options: func() []httpx.Option {
+			return []httpx.Option{
+				httpx.WithBody(conformanceItem{Name: "Ada"}),
+				httpx.WithHeaders(http.Header{"Content-Type": {"application/xml"}}),
+			}
+		},
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/items",
+			Headers:    http.Header{"Content-Type": {"application/xml"}},
+			Body:       []byte("<item><name>Ada</name></item>"),
+		},
+	},
+	{
+		Name:   "POST +json vendor type",
+		Method: http.MethodPost,
+		Path:   "/items",
+		Options: func() []httpx.Option {
+			return []httpx.Option{
+				httpx.WithBody(conformanceItem{Name: "Ada"}),
+				httpx.WithHeaders(http.Header{"Content-Type": {"application/vnd.api+json"}}),
+			}
+		},
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/items",
+			Headers:    http.Header{"Content-Type": {"application/vnd.api+json"}},
+			Body:       []byte(`{"name":"Ada"}`),
+		},
+	},
+	{
+		Name:   "POST multipart with fixed boundary",
+		Method: http.MethodPost,
+		Path:   "/upload",
+		Options: func() []httpx.Option {
+			return []httpx.Option{
+				httpx.WithMultipart(map[string]string{"b": "2", "a": "1"},
+					httpx.MultipartFile{Name: "f", Filename: "f.txt", ContentType: "text/plain", Reader: strings.NewReader("hi")}),
+				httpx.WithMultipartBoundary("conformance"),
+			}
+		},
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/upload",
+			Headers:    http.Header{"Content-Type": {"multipart/form-data; boundary=conformance"}},
+			Body: []byte("--conformance\r\n" +
+				"Content-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n" +
+				"--conformance\r\n" +
+				"Content-Disposition: form-data; name=\"b\"\r\n\r\n2\r\n" +
+				"--conformance\r\n" +
+				"Content-Disposition: form-data; name=\"f\"; filename=\"f.txt\"\r\n" +
+				"Content-Type: text/plain\r\n\r\nhi\r\n" +
+				"--conformance--\r\n"),
+		},
+	},
+	{
+		Name:   "PUT via method override",
+		Method: http.MethodPut,
+		Path:   "/items/1",
+		Options: func() []httpx.Option {
+			return []httpx.Option{httpx.WithBody(conformanceItem{Name: "Ada"}), httpx.WithMethodOverride()}
+		},
+		Want: WireRequest{
+			Method:     http.MethodPost,
+			RequestURI: "/items/1",
+			Headers:    http.Header{httpx.MethodOverrideHeader: {http.MethodPut}},
+			Body:       []byte(`{"name":"Ada"}`),
+		},
+	},
+	{
+		Name:    "DELETE with basic auth",
+		Method:  http.MethodDelete,
+		Path:    "/items/1",
+		Options: func() []httpx.Option { return []httpx.Option{httpx.WithBasicAuth("ada", "s3cret")} },
+		Want: WireRequest{
+			Method:     http.MethodDelete,
+			RequestURI: "/items/1",
+			Headers:    http.Header{"Authorization": {"Basic YWRhOnMzY3JldA=="}},
+		},
+	},
+	{
+		Name:   "per-request headers",
+		Method: http.MethodGet,
+		Path:   "/items?fields=name",
+		Options: func() []httpx.Option {
+			return []httpx.Option{
+				httpx.WithHeaders(http.Header{"X-Tenant": {"acme"}}),
+				httpx.WithBearerToken("t0k3n"),
+			}
+		},
+		Want: WireRequest{
+			Method:     http.MethodGet,
+			RequestURI: "/items?fields=name",
+			Headers:    http.Header{"X-Tenant": {"acme"}, "Authorization": {"Bearer t0k3n"}},
+		},
+	},
+}
+
+// WireRecorder is an http.Handler that records the last request it
+// received and answers with 204 No Content.
+type WireRecorder struct {
+	mu   sync.Mutex
+	last *WireRequest
+}
+
+// NewWireRecorder returns an empty WireRecorder.
+func NewWireRecorder() *WireRecorder {
+	return &WireRecorder{}
+}
+
+// ServeHTTP implements http.Handler.
+func (r *WireRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.last = &WireRequest{
+		Method:     req.Method,
+		RequestURI: req.URL.RequestURI(),
+		Headers:    req.Header.Clone(),
+		Body:       body,
+	}
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// take returns and clears the last recorded request.
+func (r *WireRecorder) take() *WireRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := r.last
+	r.last = nil
+	return last
+}
+
+// RunConformance runs Scenarios against an in-process server and fails the
+// test for every wire request that differs from its fixture. Each scenario
+// runs as a subtest.
+//
+// Example:
+//
+//	func TestConformance(t *testing.T) {
+//	    httpxtest.RunConformance(t, httpx.New(nil))
+//	}
+func RunConformance(t *testing.T, c httpx.Client) {
+	t.Helper()
+
+	rec := NewWireRecorder()
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	RunConformanceWith(t, c, srv.URL, rec)
+}
+
+// RunConformanceWith is like RunConformance for clients that do not reach
+// the network, such as mocks, which must route requests to rec. baseURL is
+// prepended to the scenario paths.
+//
+// Example:
+//
+//	rec := httpxtest.NewWireRecorder()
+//	httpxtest.RunConformanceWith(t, httpx.NewMockClient(rec, nil), "http://conformance.test", rec)
+func RunConformanceWith(t *testing.T, c httpx.Client, baseURL string, rec *WireRecorder) {
+	t.Helper()
+
+	for _, s := range Scenarios {
+		t.Run(s.Name, func(t *testing.T) {
+			var opts []httpx.Option
+			if s.Options != nil {
+				opts = s.Options()
+			}
+
+			res, err := c.Do(s.Method, baseURL+s.Path, opts...)
+			if err != nil {
+				t.Fatalf("httpxtest: request failed: %v", err)
+			}
+			res.Body.Close()
+
+			got := rec.take()
+			if got == nil {
+				t.Fatalf("httpxtest: no request reached the recorder")
+			}
+			for _, diff := range s.Want.diff(got) {
+				t.Error("httpxtest: " + diff)
+			}
+		})
+	}
+}
+
+// diff lists the differences between the fixture w and the received
+// request got.
+func (w WireRequest) diff(got *WireRequest) []string {
+	var diffs []string
+
+	if got.Method != w.Method {
+		diffs = append(diffs, fmt.Sprintf("method %s, want %s", got.Method, w.Method))
+	}
+	if got.RequestURI != w.RequestURI {
+		diffs = append(diffs, fmt.Sprintf("request URI %q, want %q", got.RequestURI, w.RequestURI))
+	}
+	for key, want := range w.Headers {
+		if values := got.Headers.Values(key); !slices.Equal(values, want) {
+			diffs = append(diffs, fmt.Sprintf("header %s %q, want %q", key, values, want))
+		}
+	}
+	if !bytes.Equal(got.Body, w.Body) {
+		diffs = append(diffs, fmt.Sprintf("body %q, want %q", got.Body, w.Body))
+	}
+
+	return diffs
+}
@@ -0,0 +1,88 @@
+package httpxtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/yousef-muc/httpx"
+)
+
+func TestConformance(t *testing.T) {
+	t.Run("client", func(t *testing.T) {
+		c := httpx.New(nil)
+		defer c.Close(context.Background())
+
+		RunConformance(t, c)
+	})
+
+	t.Run("mock", func(t *testing.T) {
+		rec := NewWireRecorder()
+		c := httpx.NewMockClient(rec, nil)
+		defer c.Close(context.Background())
+
+		RunConformanceWith(t, c, "http://conformance.test", rec)
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		rec := NewWireRecorder()
+		srv := httptest.NewServer(rec)
+		defer srv.Close()
+
+		c := httpx.New(nil)
+		defer c.Close(context.Background())
+		legacy := httpx.Legacy(c)
+
+		// The scenarios expressible as positional arguments, sent through
+		// the adapter, must produce the same wire requests as the fixtures
+		calls := map[string]func() (*http.Response, error){
+			"GET with sorted params": func() (*http.Response, error) {
+				return legacy.Get(srv.URL+"/items", nil, map[string]string{"b": "2", "a": "1"})
+			},
+			"POST JSON struct": func() (*http.Response, error) {
+				return legacy.Post(srv.URL+"/items", nil, nil, conformanceItem{Name: "Ada"})
+			},
+			"POST string as text": func() (*http.Response, error) {
+				return legacy.Post(srv.URL+"/notes", nil, nil, "hello")
+			},
+			"POST bytes as octet-stream": func() (*http.Response, error) {
+				return legacy.Post(srv.URL+"/blobs", nil, nil, []byte{0x00, 0xff})
+			},
+			"POST url.Values as form": func() (*http.Response, error) {
+				return legacy.Post(srv.URL+"/login", nil, nil, url.Values{"user": {"ada"}, "pass": {"s3cret"}})
+			},
+			"POST XML struct": func() (*http.Response, error) {
+				return legacy.Post(srv.URL+"/items", http.Header{"Content-Type": {"application/xml"}}, nil, conformanceItem{Name: "Ada"})
+			},
+			"per-request headers": func() (*http.Response, error) {
+				return legacy.Get(srv.URL+"/items?fields=name",
+					http.Header{"X-Tenant": {"acme"}, "Authorization": {"Bearer t0k3n"}}, nil)
+			},
+		}
+
+		for _, s := range Scenarios {
+			call, ok := calls[s.Name]
+			if !ok {
+				continue
+			}
+
+			t.Run(s.Name, func(t *testing.T) {
+				res, err := call()
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				res.Body.Close()
+
+				got := rec.take()
+				if got == nil {
+					t.Fatal("no request reached the recorder")
+				}
+				for _, diff := range s.Want.diff(got) {
+					t.Error(diff)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,37 @@
+// Package httpxtest provides helpers for testing code built on httpx.
+package httpxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yousef-muc/httpx"
+)
+
+// CloseTimeout bounds how long VerifyNoLeaks waits for the client to close.
+var CloseTimeout = 5 * time.Second
+
+// VerifyNoLeaks closes the client and fails the test if any background
+// goroutine spawned by it is still running afterwards. It is typically
+// deferred right after the client is created.
+//
+// Example:
+//
+//	client := httpx.New(cfg)
+//	defer httpxtest.VerifyNoLeaks(t, client)
+func VerifyNoLeaks(t testing.TB, c httpx.Client) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), CloseTimeout)
+	defer cancel()
+
+	if err := c.Close(ctx); err != nil {
+		t.Errorf("httpxtest: closing client: %v", err)
+	}
+
+	for _, task := range c.Tasks() {
+		t.Errorf("httpxtest: leaked task %d %q started at %s",
+			task.ID, task.Name, task.Started.Format(time.RFC3339Nano))
+	}
+}
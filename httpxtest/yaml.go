@@ -0,0 +1,551 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// isYAMLPath reports whether path names a YAML cassette, compressed or not.
+func isYAMLPath(path string) bool {
+	path = strings.TrimSuffix(path, ".gz")
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// marshalYAML encodes c as block-style YAML. Strings are always written
+// double-quoted, so that no value is mistaken for a number or boolean.
+func (c *Cassette) marshalYAML() []byte {
+	var b bytes.Buffer
+
+	if len(c.Interactions) == 0 {
+		b.WriteString("interactions: []\n")
+		return b.Bytes()
+	}
+
+	b.WriteString("interactions:\n")
+	for _, in := range c.Interactions {
+		b.WriteString("  - request:\n")
+		yamlScalar(&b, 6, "method", yamlQuote(in.Request.Method))
+		yamlScalar(&b, 6, "url", yamlQuote(in.Request.URL))
+		yamlHeaders(&b, 6, in.Request.Headers)
+		yamlBody(&b, 6, in.Request.Body)
+
+		b.WriteString("    response:\n")
+		yamlScalar(&b, 6, "status_code", strconv.Itoa(in.Response.StatusCode))
+		yamlHeaders(&b, 6, in.Response.Headers)
+		yamlBody(&b, 6, in.Response.Body)
+	}
+
+	return b.Bytes()
+}
+
+// yamlScalar writes a "key: value" line.
+func yamlScalar(b *bytes.Buffer, indent int, key, value string) {
+	fmt.Fprintf(b, "%s%s: %s\n", strings.Repeat(" ", indent), key, value)
+}
+
+// yamlHeaders writes h sorted by name, omitting empty headers like the
+// JSON encoding does.
+func yamlHeaders(b *bytes.Buffer, indent int, h http.Header) {
+	if len(h) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pad := strings.Repeat(" ", indent)
+	fmt.Fprintf(b, "%sheaders:\n", pad)
+	for _, name := range names {
+		if len(h[name]) == 0 {
+			fmt.Fprintf(b, "%s  %s: []\n", pad, yamlQuote(name))
+			continue
+		}
+		fmt.Fprintf(b, "%s  %s:\n", pad, yamlQuote(name))
+		for _, v := range h[name] {
+			fmt.Fprintf(b, "%s    - %s\n", pad, yamlQuote(v))
+		}
+	}
+}
+
+// yamlBody writes a stored body.
+func yamlBody(b *bytes.Buffer, indent int, body Body) {
+	fmt.Fprintf(b, "%sbody:\n", strings.Repeat(" ", indent))
+	if body.Encoding != "" {
+		yamlScalar(b, indent+2, "encoding", yamlQuote(body.Encoding))
+	}
+	yamlScalar(b, indent+2, "data", yamlQuote(body.Data))
+}
+
+// yamlQuote returns s as a double-quoted YAML scalar. The escapes of
+// strconv.Quote are a subset of those YAML accepts.
+func yamlQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// unmarshalYAML decodes a YAML cassette. It understands the block-style
+// subset cassettes are written in, plus what hand edits commonly add:
+// comments, plain and single-quoted scalars, literal blocks ("|", "|-",
+// "|+"), and flow sequences of scalars.
+func unmarshalYAML(data []byte, c *Cassette) error {
+	p := &yamlParser{}
+	for n, raw := range strings.Split(string(data), "\n") {
+		p.lines = append(p.lines, newYAMLLine(n+1, strings.TrimSuffix(raw, "\r")))
+	}
+
+	p.skipBlank()
+	if p.done() {
+		return fmt.Errorf("empty document")
+	}
+
+	tree, err := p.node(p.lines[p.pos].indent)
+	if err != nil {
+		return err
+	}
+	if p.skipBlank(); !p.done() {
+		return p.errorf("unexpected indentation")
+	}
+
+	// The tree only holds JSON types, so the JSON field tags apply as is
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, c)
+}
+
+// yamlLine is a source line split into indentation and content.
+type yamlLine struct {
+	number  int
+	raw     string
+	indent  int
+	content string
+}
+
+func newYAMLLine(number int, raw string) yamlLine {
+	content := strings.TrimLeft(raw, " ")
+	return yamlLine{
+		number:  number,
+		raw:     raw,
+		indent:  len(raw) - len(content),
+		content: strings.TrimRight(content, " \t"),
+	}
+}
+
+// blank reports whether the line holds no node, i.e. it is empty, a
+// comment or a document marker.
+func (l yamlLine) blank() bool {
+	return l.content == "" || l.content[0] == '#' || l.content == "---"
+}
+
+// yamlParser parses block-style YAML line by line.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) done() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) skipBlank() {
+	for !p.done() && p.lines[p.pos].blank() {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	line := len(p.lines)
+	if !p.done() {
+		line = p.lines[p.pos].number
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// node parses the block node starting at the current line, whose content
+// begins at column indent.
+func (p *yamlParser) node(indent int) (any, error) {
+	line := p.lines[p.pos]
+	if strings.HasPrefix(line.content, "\t") {
+		return nil, p.errorf("tabs are not allowed for indentation")
+	}
+
+	switch {
+	case line.content == "-" || strings.HasPrefix(line.content, "- "):
+		return p.sequence(indent)
+	case mappingKeyEnd(line.content) >= 0:
+		return p.mapping(indent)
+	default:
+		p.pos++
+		return yamlScalarValue(line.content)
+	}
+}
+
+// sequence parses "- item" lines at indent.
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+
+	for p.skipBlank(); !p.done(); p.skipBlank() {
+		line := p.lines[p.pos]
+		if line.indent != indent || !(line.content == "-" || strings.HasPrefix(line.content, "- ")) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.content, "-"), " ")
+		if rest == "" || rest[0] == '#' {
+			p.pos++
+			item, err := p.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// The item continues as a node of its own at the column after "- "
+		column := indent + len(line.content) - len(rest)
+		p.lines[p.pos] = yamlLine{number: line.number, raw: line.raw, indent: column, content: rest}
+		item, err := p.node(column)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// mapping parses "key: value" lines at indent.
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+
+	for p.skipBlank(); !p.done(); p.skipBlank() {
+		line := p.lines[p.pos]
+		if line.indent != indent {
+			if line.indent > indent {
+				return nil, p.errorf("unexpected indentation")
+			}
+			break
+		}
+
+		end := mappingKeyEnd(line.content)
+		if end < 0 {
+			return nil, p.errorf("expected \"key: value\"")
+		}
+
+		key, err := yamlKey(line.content[:end])
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+
+		value := strings.TrimSpace(line.content[end+1:])
+		p.pos++
+
+		switch {
+		case value == "" || value[0] == '#':
+			m[key], err = p.child(indent)
+		case value[0] == '|':
+			m[key], err = p.literal(indent, value)
+		default:
+			m[key], err = yamlScalarValue(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// child parses the node nested below a line at indent: more indented lines,
+// or a sequence at the same indentation, which YAML allows as a mapping
+// value. Without either, the value is null.
+func (p *yamlParser) child(indent int) (any, error) {
+	p.skipBlank()
+	if p.done() {
+		return nil, nil
+	}
+
+	line := p.lines[p.pos]
+	sameLevelSequence := line.indent == indent && (line.content == "-" || strings.HasPrefix(line.content, "- "))
+	if line.indent > indent || sameLevelSequence {
+		return p.node(line.indent)
+	}
+	return nil, nil
+}
+
+// literal parses a literal block scalar introduced by header ("|", "|-" or
+// "|+") on a line at indent.
+func (p *yamlParser) literal(indent int, header string) (any, error) {
+	chomp := strings.TrimSpace(strings.SplitN(header[1:], "#", 2)[0])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, p.errorf("unsupported block scalar header %q", header)
+	}
+
+	var lines []string
+	blockIndent := -1
+	for ; !p.done(); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			return nil, p.errorf("block scalar line is less indented than the first")
+		}
+		lines = append(lines, line.raw[blockIndent:])
+	}
+
+	// Trailing blank lines belong to the chomping, not the content
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	text := strings.Join(lines, "\n")
+	switch {
+	case len(lines) == 0 && chomp != "+":
+		return "", nil
+	case chomp == "-":
+		return text, nil
+	case chomp == "+":
+		return text + strings.Repeat("\n", trailing+1), nil
+	default:
+		return text + "\n", nil
+	}
+}
+
+// mappingKeyEnd returns the index of the colon ending the key of a mapping
+// entry in content, or -1 if content is not one.
+func mappingKeyEnd(content string) int {
+	if content == "" {
+		return -1
+	}
+
+	start := 0
+	if q := content[0]; q == '"' || q == '\'' {
+		end := quotedEnd(content)
+		if end < 0 {
+			return -1
+		}
+		start = end
+	}
+
+	for i := start; i < len(content); i++ {
+		if content[i] == ':' && (i+1 == len(content) || content[i+1] == ' ') {
+			return i
+		}
+		if content[i] == ' ' && i+1 < len(content) && content[i+1] == '#' {
+			return -1
+		}
+	}
+	return -1
+}
+
+// quotedEnd returns the index after the closing quote of the quoted scalar
+// at the start of s, or -1 if it is not closed.
+func quotedEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// yamlKey returns the string value of a mapping key.
+func yamlKey(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		return unquoteYAML(s)
+	}
+	return s, nil
+}
+
+// yamlInt matches plain integer scalars.
+var yamlInt = regexp.MustCompile(`^[-+]?[0-9]+$`)
+
+// yamlScalarValue converts a scalar or flow sequence to its JSON value.
+func yamlScalarValue(s string) (any, error) {
+	switch {
+	case s[0] == '"' || s[0] == '\'':
+		end := quotedEnd(s)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated quoted scalar %s", s)
+		}
+		if rest := strings.TrimSpace(s[end:]); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("unexpected %q after quoted scalar", rest)
+		}
+		return unquoteYAML(s[:end])
+
+	case s[0] == '[':
+		return yamlFlowSequence(s)
+
+	case s == "{}":
+		return map[string]any{}, nil
+	}
+
+	// Plain scalars end at a comment
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+
+	switch s {
+	case "", "~", "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if yamlInt.MatchString(s) {
+		return json.Number(strings.TrimPrefix(s, "+")), nil
+	}
+	return s, nil
+}
+
+// yamlFlowSequence parses a single-line flow sequence of scalars, e.g.
+// ["gzip", "br"].
+func yamlFlowSequence(s string) (any, error) {
+	if i := strings.LastIndexByte(s, ']'); i >= 0 {
+		if rest := strings.TrimSpace(s[i+1:]); rest == "" || rest[0] == '#' {
+			s = s[:i+1]
+		}
+	}
+	if !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("unterminated flow sequence %s", s)
+	}
+
+	items := []any{}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	for inner != "" {
+		var item string
+		if inner[0] == '"' || inner[0] == '\'' {
+			end := quotedEnd(inner)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted scalar in %s", s)
+			}
+			item, inner = inner[:end], strings.TrimSpace(inner[end:])
+		} else {
+			end := strings.IndexByte(inner, ',')
+			if end < 0 {
+				end = len(inner)
+			}
+			item, inner = strings.TrimSpace(inner[:end]), inner[end:]
+		}
+
+		value, err := yamlScalarValue(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+
+		if inner != "" {
+			if inner[0] != ',' {
+				return nil, fmt.Errorf("expected ',' in %s", s)
+			}
+			inner = strings.TrimSpace(inner[1:])
+		}
+	}
+	return items, nil
+}
+
+// unquoteYAML decodes a single- or double-quoted scalar.
+func unquoteYAML(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+
+	var b strings.Builder
+	body := s[1 : len(s)-1]
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(body) {
+			return "", fmt.Errorf("trailing backslash in %s", s)
+		}
+
+		switch body[i] {
+		case '0':
+			b.WriteByte(0)
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 't', '\t':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'v':
+			b.WriteByte('\v')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case 'e':
+			b.WriteByte(0x1b)
+		case ' ', '"', '/', '\\':
+			b.WriteByte(body[i])
+		case 'N':
+			b.WriteString("\u0085")
+		case '_':
+			b.WriteString("\u00a0")
+		case 'L':
+			b.WriteString("\u2028")
+		case 'P':
+			b.WriteString("\u2029")
+		case 'x', 'u', 'U':
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[body[i]]
+			if i+digits >= len(body) {
+				return "", fmt.Errorf("short escape in %s", s)
+			}
+			code, err := strconv.ParseUint(body[i+1:i+1+digits], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape in %s", s)
+			}
+			if body[i] == 'x' {
+				// Go's strconv.Quote writes invalid UTF-8 as \x bytes
+				b.WriteByte(byte(code))
+			} else {
+				if !utf8.ValidRune(rune(code)) {
+					return "", fmt.Errorf("invalid code point in %s", s)
+				}
+				b.WriteRune(rune(code))
+			}
+			i += digits
+		default:
+			return "", fmt.Errorf("unknown escape \\%c in %s", body[i], s)
+		}
+	}
+	return b.String(), nil
+}
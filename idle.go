@@ -0,0 +1,91 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// ErrIdleTimeout is returned when a request configured with WithIdleTimeout
+// saw no progress for longer than the idle timeout.
+var ErrIdleTimeout = errors.New("httpx: connection idle timeout exceeded")
+
+// idleWatchdog cancels a request context when no progress is observed for
+// the configured duration. Every sign of life resets the timer.
+type idleWatchdog struct {
+	timeout time.Duration
+	timer   *time.Timer
+	ctx     context.Context
+}
+
+// watchIdle derives a context from ctx that is cancelled with ErrIdleTimeout
+// after d without progress. The returned cancel func releases the watchdog.
+func watchIdle(ctx context.Context, d time.Duration) (*idleWatchdog, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	w := &idleWatchdog{
+		timeout: d,
+		timer:   time.AfterFunc(d, func() { cancel(ErrIdleTimeout) }),
+		ctx:     ctx,
+	}
+
+	return w, ctx, func() {
+		w.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// touch records progress and restarts the idle timer.
+func (w *idleWatchdog) touch() {
+	w.timer.Reset(w.timeout)
+}
+
+// trace resets the idle timer on connection-level progress while the
+// request is written and the response headers are awaited.
+func (w *idleWatchdog) trace(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotConn:              func(httptrace.GotConnInfo) { w.touch() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { w.touch() },
+		GotFirstResponseByte: w.touch,
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// wrap converts a failure caused by the watchdog into an ErrIdleTimeout
+// error. Other errors are returned unchanged.
+func (w *idleWatchdog) wrap(err error) error {
+	if err != nil && context.Cause(w.ctx) == ErrIdleTimeout {
+		return fmt.Errorf("%w after %s without progress", ErrIdleTimeout, w.timeout)
+	}
+	return err
+}
+
+// idleClient returns a variant of httpClient without the client-wide
+// total timeout and response header timeout, used for idle-watched requests
+// and WithNoTimeout. A custom Config.Transport keeps its own timeouts.
+func (c *client) idleClient(httpClient *http.Client) *http.Client {
+	// Fresh connections keep a non-pooling transport
+	if httpClient == c.freshClient {
+		return c.idleFresh
+	}
+
+	return c.idleEngine
+}
+
+// idleBody resets the watchdog whenever response bytes arrive.
+type idleBody struct {
+	io.ReadCloser
+	watchdog *idleWatchdog
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.watchdog.touch()
+	}
+	return n, b.watchdog.wrap(err)
+}
@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeoutLiftsClientTimeouts(t *testing.T) {
+	srv := slowServer(t, 200*time.Millisecond, 0)
+
+	c := New(&Config{
+		RequestTimeout:        100 * time.Millisecond,
+		ResponseHeaderTimeout: 50 * time.Millisecond,
+	})
+	defer c.Close(context.Background())
+
+	if _, err := c.Get(srv.URL); err == nil {
+		t.Fatal("client timeouts did not apply")
+	}
+
+	tests := map[string][]Option{
+		"WithIdleTimeout":                      {WithIdleTimeout(time.Second)},
+		"WithIdleTimeout, WithFreshConnection": {WithIdleTimeout(time.Second), WithFreshConnection()},
+		"WithNoTimeout":                        {WithNoTimeout()},
+		"WithNoTimeout, WithFreshConnection":   {WithNoTimeout(), WithFreshConnection()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := c.Get(srv.URL, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readBodyWithStatus(res); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestIdleTimeoutFiresWithoutProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	_, err := c.Get(srv.URL, WithIdleTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("err = %v, want ErrIdleTimeout", err)
+	}
+}
+
+func TestIdleTimeoutLongPoll(t *testing.T) {
+	// The poll outlasts the request timeout, but is never silent for long
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		for range 8 {
+			w.Write([]byte("."))
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+		w.Write([]byte("event"))
+	}))
+	defer srv.Close()
+
+	c := New(&Config{RequestTimeout: 50 * time.Millisecond})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithIdleTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "........event" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestIdleTimeoutFiresMidBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithIdleTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBodyWithStatus(res); !errors.Is(err, ErrIdleTimeout) {
+		t.Errorf("err = %v, want ErrIdleTimeout", err)
+	}
+}
@@ -0,0 +1,158 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// RequestInterceptor inspects or mutates an outgoing request before it is
+// sent. Returning an error aborts the call with that error.
+type RequestInterceptor func(*http.Request) error
+
+// ResponseInterceptor inspects or mutates a response before it is returned
+// to the caller, for example by wrapping its body. Returning an error closes
+// the response body and fails the call with that error.
+type ResponseInterceptor func(*http.Response) error
+
+// ResponseHook receives the response of a single request and returns the
+// response handed to the caller, which may be res itself or a replacement.
+// Returning an error closes the body of res and fails the call with that
+// error.
+type ResponseHook func(res *http.Response) (*http.Response, error)
+
+// ResponseValidator checks a response before it is returned to the caller,
+// e.g. its status, Content-Type or a field of the decoded body. Returning an
+// error closes the response body and fails the call with that error. The
+// body may be read with the response helpers; it is replayed to the caller.
+type ResponseValidator func(*http.Response) error
+
+// interceptors holds the registered request and response interceptors of a
+// client. Registration may happen concurrently with in-flight requests.
+type interceptors struct {
+	mu       sync.RWMutex
+	request  []RequestInterceptor
+	response []ResponseInterceptor
+}
+
+// OnRequest registers an interceptor that runs before every request is sent.
+// Interceptors run in registration order.
+//
+// Example:
+//
+//	client.OnRequest(func(req *http.Request) error {
+//	    req.Header.Set("Authorization", "Bearer "+tokens.Current())
+//	    return nil
+//	})
+func (c *client) OnRequest(fn RequestInterceptor) {
+	c.interceptors.mu.Lock()
+	defer c.interceptors.mu.Unlock()
+
+	c.interceptors.request = append(c.interceptors.request, fn)
+}
+
+// OnResponse registers an interceptor that runs after every response is
+// received. Interceptors run in registration order.
+//
+// Example:
+//
+//	client.OnResponse(func(res *http.Response) error {
+//	    if res.StatusCode == http.StatusUnauthorized {
+//	        tokens.Invalidate()
+//	    }
+//	    return nil
+//	})
+func (c *client) OnResponse(fn ResponseInterceptor) {
+	c.interceptors.mu.Lock()
+	defer c.interceptors.mu.Unlock()
+
+	c.interceptors.response = append(c.interceptors.response, fn)
+}
+
+// interceptRequest runs all request interceptors in order and stops at the
+// first error.
+func (c *client) interceptRequest(req *http.Request) error {
+	c.interceptors.mu.RLock()
+	chain := c.interceptors.request
+	c.interceptors.mu.RUnlock()
+
+	for _, fn := range chain {
+		if err := fn(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interceptResponse runs all response interceptors in order. On error the
+// response body is closed so the connection is not leaked.
+func (c *client) interceptResponse(res *http.Response) error {
+	c.interceptors.mu.RLock()
+	chain := c.interceptors.response
+	c.interceptors.mu.RUnlock()
+
+	for _, fn := range chain {
+		if err := fn(res); err != nil {
+			res.Body.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// validateResponse runs the validators of Config.ResponseValidators and then
+// those of the request in order and stops at the first error. On error the
+// response body is closed.
+func (c *client) validateResponse(res *http.Response, perRequest []ResponseValidator) error {
+	for _, chain := range [][]ResponseValidator{c.ResponseValidators, perRequest} {
+		for _, validate := range chain {
+			if err := validate(res); err != nil {
+				res.Body.Close()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runResponseHook passes res through hook and normalizes the replacement so
+// that the response helpers can read it: a missing body becomes http.NoBody
+// and a missing request is taken over from res.
+func runResponseHook(hook ResponseHook, res *http.Response) (*http.Response, error) {
+	replaced, err := hook(res)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	if replaced == nil {
+		return res, nil
+	}
+	if replaced.Body == nil {
+		replaced.Body = http.NoBody
+	}
+	if replaced.Request == nil {
+		replaced.Request = res.Request
+	}
+
+	return replaced, nil
+}
+
+// requestValuesKey is the context key under which WithValue data travels.
+type requestValuesKey struct{}
+
+// RequestValue returns the value stored with WithValue under key for the
+// request that ctx belongs to, or nil. Use req.Context() in interceptors and
+// middlewares, and res.Request.Context() for responses.
+//
+// Example:
+//
+//	client.OnResponse(func(res *http.Response) error {
+//	    tenant, _ := httpx.RequestValue(res.Request.Context(), tenantKey{}).(string)
+//	    metrics.Inc(tenant, res.StatusCode)
+//	    return nil
+//	})
+func RequestValue(ctx context.Context, key any) any {
+	values, _ := ctx.Value(requestValuesKey{}).(map[any]any)
+	return values[key]
+}
@@ -0,0 +1,182 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upperBody upper-cases everything read through it.
+type upperBody struct{ io.ReadCloser }
+
+func (b upperBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	copy(p, strings.ToUpper(string(p[:n])))
+	return n, err
+}
+
+func TestInterceptors(t *testing.T) {
+	srv := flakyServer(t, 1)
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	var log callLog
+	c.OnRequest(func(req *http.Request) error {
+		log.add("request 1")
+		req.Header.Set("X-Step", "1")
+		return nil
+	})
+	c.OnRequest(func(req *http.Request) error {
+		log.add("request 2 after " + req.Header.Get("X-Step"))
+		return nil
+	})
+	c.OnResponse(func(res *http.Response) error {
+		log.add("response 1")
+		res.Body = upperBody{res.Body}
+		return nil
+	})
+	c.OnResponse(func(res *http.Response) error {
+		log.add("response 2 for " + res.Header.Get("X-Attempts"))
+		return nil
+	})
+
+	res, err := c.Put(srv.URL, WithBody("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := c.(*client).Text(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interceptors run once per call around the retries, in registration
+	// order, and may replace the body
+	if text != "HELLO" {
+		t.Errorf("body = %q, want it wrapped by the interceptor", text)
+	}
+	if got, want := log.take(), []string{"request 1", "request 2 after 1", "response 1", "response 2 for 2"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestInterceptorErrors(t *testing.T) {
+	srv, calls := proxyServer(t, "origin")
+
+	t.Run("request", func(t *testing.T) {
+		denied := errors.New("denied")
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		var later atomic.Int32
+		c.OnRequest(func(req *http.Request) error { return denied })
+		c.OnRequest(func(req *http.Request) error {
+			later.Add(1)
+			return nil
+		})
+
+		if _, err := c.Get(srv.URL); !errors.Is(err, denied) {
+			t.Errorf("err = %v, want the interceptor's error", err)
+		}
+		if later.Load() != 0 {
+			t.Error("later interceptors ran after an error")
+		}
+		if calls.Load() != 0 {
+			t.Error("request sent despite the error")
+		}
+	})
+
+	t.Run("response", func(t *testing.T) {
+		rejected := errors.New("rejected")
+		c := New(nil)
+		defer c.Close(context.Background())
+
+		var body *closeCounter
+		c.OnResponse(func(res *http.Response) error {
+			body = &closeCounter{ReadCloser: res.Body}
+			res.Body = body
+			return rejected
+		})
+
+		res, err := c.Get(srv.URL)
+		if !errors.Is(err, rejected) || res != nil {
+			t.Errorf("got %v, %v; want the interceptor's error", res, err)
+		}
+		if body.closes.Load() != 1 {
+			t.Errorf("body closed %d times, want once", body.closes.Load())
+		}
+	})
+}
+
+func TestRequestValue(t *testing.T) {
+	type tenantKey struct{}
+	srv, _ := proxyServer(t, "origin")
+
+	var log callLog
+	record := func(stage string, ctx context.Context) {
+		tenant, _ := RequestValue(ctx, tenantKey{}).(string)
+		log.add(stage + " " + tenant)
+	}
+
+	c := New(&Config{
+		Middlewares: []Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				record("middleware", req.Context())
+				return next.RoundTrip(req)
+			})
+		}},
+		ResponseValidators: []ResponseValidator{func(res *http.Response) error {
+			record("config validator", res.Request.Context())
+			return nil
+		}},
+	})
+	defer c.Close(context.Background())
+
+	c.OnRequest(func(req *http.Request) error {
+		record("request", req.Context())
+		return nil
+	})
+	c.OnResponse(func(res *http.Response) error {
+		record("response", res.Request.Context())
+		return nil
+	})
+
+	// A caller context value under the same key does not collide
+	ctx := context.WithValue(context.Background(), tenantKey{}, "caller")
+	res, err := c.Get(srv.URL,
+		WithContext(ctx),
+		WithValue(tenantKey{}, "acme"),
+		WithResponseHook(func(res *http.Response) (*http.Response, error) {
+			record("hook", res.Request.Context())
+			return nil, nil
+		}),
+		WithResponseValidatorChain(func(res *http.Response) error {
+			record("request validator", res.Request.Context())
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	want := []string{"request acme", "middleware acme", "response acme", "hook acme", "config validator acme", "request validator acme"}
+	if got := log.take(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+
+	// Without WithValue nothing is found
+	res, err = c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := log.take(); !slices.Equal(got, []string{"request ", "middleware ", "response ", "config validator "}) {
+		t.Errorf("calls = %v, want no values", got)
+	}
+}
@@ -0,0 +1,64 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+)
+
+// lazyTLS defers loading the TLS material of Config.LazyInit clients, such
+// as certificate and CA files, until the first request needs it.
+//
+// A failed load is not remembered: the request that triggered it returns
+// the error and the next request tries again. The transports only receive
+// the configuration once it loaded completely.
+type lazyTLS struct {
+	load func() (*tls.Config, error)
+
+	mu         sync.Mutex
+	done       bool
+	transports []*http.Transport // receive the configuration once loaded
+}
+
+// init loads the TLS configuration unless that already succeeded.
+func (l *lazyTLS) init() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return nil
+	}
+
+	tlsConfig, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	// No request has used the transports yet; they all waited for init
+	for _, t := range l.transports {
+		if tlsConfig != nil {
+			t.TLSClientConfig = tlsConfig.Clone()
+		}
+	}
+
+	l.done = true
+	return nil
+}
+
+// lazyTransport initializes lazyTLS before the first round trip through
+// base, so that requests sent via HTTPClient are covered as well.
+type lazyTransport struct {
+	lazy *lazyTLS
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *lazyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.lazy.init(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
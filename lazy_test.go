@@ -0,0 +1,154 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lazyState returns the lazy TLS loader shared by the engines of c.
+func lazyState(t *testing.T, c Client) *lazyTLS {
+	t.Helper()
+
+	traffic := c.(*client).httpClient.Transport.(*trafficTransport)
+	lazy, ok := traffic.base.(*lazyTransport)
+	if !ok {
+		t.Fatalf("engine transport is %T, want *lazyTransport", traffic.base)
+	}
+	return lazy.lazy
+}
+
+func TestLazyInitLoadError(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, []byte("not a certificate"), 0o600)
+
+	// The eager client reports the error the lazy one must defer
+	_, eagerErr := NewClient(&Config{RootCAFile: caFile})
+	if eagerErr == nil {
+		t.Fatal("NewClient accepted a CA file without PEM")
+	}
+
+	c, err := NewClient(&Config{RootCAFile: caFile, LazyInit: true})
+	if err != nil {
+		t.Fatalf("NewClient with LazyInit failed: %v", err)
+	}
+	defer c.Close(context.Background())
+	lazy := lazyState(t, c)
+
+	var first string
+	for i := range 3 {
+		_, err := c.Get(srv.URL)
+		if err == nil {
+			t.Fatalf("request %d succeeded with a bad CA file", i)
+		}
+		if !strings.Contains(err.Error(), eagerErr.Error()) {
+			t.Errorf("request %d: err = %v, want it to contain %q", i, err, eagerErr)
+		}
+		if i == 0 {
+			first = err.Error()
+		} else if err.Error() != first {
+			t.Errorf("request %d: err = %q, want %q", i, err, first)
+		}
+
+		// Failed loads leave every engine untouched
+		if lazy.done {
+			t.Fatal("failed load marked as done")
+		}
+		for _, transport := range lazy.transports {
+			if transport.TLSClientConfig != nil {
+				t.Fatalf("request %d: transport received a partial TLS config", i)
+			}
+		}
+	}
+
+	// Once the file is fixed the next request loads it
+	writePEM(t, filepath.Dir(caFile), "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request after fixing the CA file failed: %v", err)
+	}
+	res.Body.Close()
+
+	for _, transport := range lazy.transports {
+		if transport.TLSClientConfig == nil || transport.TLSClientConfig.RootCAs == nil {
+			t.Error("transport did not receive the loaded TLS config")
+		}
+	}
+}
+
+// benchmarkConfig returns a client configuration touching most of New:
+// TLS files, a base URL, a proxy, retries, timeouts and headers.
+func benchmarkConfig(b *testing.B) *Config {
+	b.Helper()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	b.Cleanup(srv.Close)
+
+	dir := b.TempDir()
+	_, certFile, keyFile := clientCertificate(b, dir)
+
+	return &Config{
+		BaseURL:        "https://api.example.com/v1/",
+		ProxyURL:       "http://proxy.example.com:3128",
+		RootCAFile:     writePEM(b, dir, "ca.pem", "CERTIFICATE", srv.Certificate().Raw),
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+		MinTLSVersion:  tls.VersionTLS12,
+		RequestTimeout: 30 * time.Second,
+		Retry:          &RetryConfig{MaxRetries: 3},
+		Headers:        http.Header{"User-Agent": {"bench/1.0"}, "Accept": {"application/json"}},
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	configured := benchmarkConfig(b)
+
+	for _, bc := range []struct {
+		name string
+		cfg  *Config
+	}{{"defaults", nil}, {"configured", configured}} {
+		cfg := bc.cfg
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				c, err := NewClient(cfg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				c.Close(context.Background())
+			}
+		})
+	}
+}
+
+func BenchmarkNewLazy(b *testing.B) {
+	configured := *benchmarkConfig(b)
+	configured.LazyInit = true
+
+	for _, bc := range []struct {
+		name string
+		cfg  *Config
+	}{{"defaults", &Config{LazyInit: true}}, {"configured", &configured}} {
+		cfg := bc.cfg
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				c, err := NewClient(cfg)
+				if err != nil {
+					b.Fatal(err)
+				}
+				c.Close(context.Background())
+			}
+		})
+	}
+}
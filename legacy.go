@@ -0,0 +1,91 @@
+package httpx
+
+import "net/http"
+
+// LegacyClient exposes the positional call shape of early httpx versions,
+// Get(url, headers, params) and Post(url, headers, params, body), on top of
+// a Client using options. It lets call sites migrate file by file.
+//
+// Nil headers, params and bodies are skipped, exactly as if the matching
+// option had not been passed.
+//
+// Deprecated: call the Client methods with WithHeaders, WithParams and
+// WithBody instead.
+type LegacyClient struct {
+	c Client
+}
+
+// Legacy wraps c in the positional compatibility adapter.
+//
+// Example:
+//
+//	legacy := httpx.Legacy(client)
+//	res, err := legacy.Get("https://api.com/users", nil, map[string]string{"limit": "10"})
+//
+// Deprecated: call the Client methods with options instead.
+func Legacy(c Client) LegacyClient {
+	return LegacyClient{c: c}
+}
+
+// legacyOptions converts positional arguments into options.
+func legacyOptions(headers http.Header, params map[string]string, body any) []Option {
+	var opts []Option
+	if headers != nil {
+		opts = append(opts, WithHeaders(headers))
+	}
+	if params != nil {
+		opts = append(opts, WithParams(params))
+	}
+	if body != nil {
+		opts = append(opts, WithBody(body))
+	}
+	return opts
+}
+
+// Get performs a GET request with optional headers and query parameters.
+//
+// Deprecated: use Client.Get with WithHeaders and WithParams.
+func (l LegacyClient) Get(url string, headers http.Header, params map[string]string) (*http.Response, error) {
+	return l.c.Get(url, legacyOptions(headers, params, nil)...)
+}
+
+// Post performs a POST request with optional headers, query parameters and
+// body.
+//
+// Deprecated: use Client.Post with WithHeaders, WithParams and WithBody.
+func (l LegacyClient) Post(url string, headers http.Header, params map[string]string, body any) (*http.Response, error) {
+	return l.c.Post(url, legacyOptions(headers, params, body)...)
+}
+
+// Put performs a PUT request with optional headers, query parameters and
+// body.
+//
+// Deprecated: use Client.Put with WithHeaders, WithParams and WithBody.
+func (l LegacyClient) Put(url string, headers http.Header, params map[string]string, body any) (*http.Response, error) {
+	return l.c.Put(url, legacyOptions(headers, params, body)...)
+}
+
+// Patch performs a PATCH request with optional headers, query parameters and
+// body.
+//
+// Deprecated: use Client.Patch with WithHeaders, WithParams and WithBody.
+func (l LegacyClient) Patch(url string, headers http.Header, params map[string]string, body any) (*http.Response, error) {
+	return l.c.Patch(url, legacyOptions(headers, params, body)...)
+}
+
+// Delete performs a DELETE request with optional headers and query
+// parameters.
+//
+// Deprecated: use Client.Delete with WithHeaders and WithParams.
+func (l LegacyClient) Delete(url string, headers http.Header, params map[string]string) (*http.Response, error) {
+	return l.c.Delete(url, legacyOptions(headers, params, nil)...)
+}
+
+// DeleteWithBody performs a DELETE request that carries a body, which the
+// options API only sends together with WithBodyAllowed.
+//
+// Deprecated: use Client.Delete with WithBody and WithBodyAllowed.
+func (l LegacyClient) DeleteWithBody(url string, headers http.Header, params map[string]string, body any) (*http.Response, error) {
+	opts := legacyOptions(headers, params, body)
+	return l.c.Delete(url, append(opts, WithBodyAllowed())...)
+}
@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Token", r.Header.Get("X-Token"))
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+	legacy := Legacy(c)
+
+	headers := http.Header{"X-Token": {"t0k3n"}}
+	params := map[string]string{"limit": "10"}
+	body := map[string]string{"name": "John"}
+
+	tests := []struct {
+		name string
+		call func() (*http.Response, error)
+		opts func() (*http.Response, error) // the equivalent options call
+	}{
+		{"Get", func() (*http.Response, error) { return legacy.Get(srv.URL, headers, params) },
+			func() (*http.Response, error) { return c.Get(srv.URL, WithHeaders(headers), WithParams(params)) }},
+		{"Get without arguments", func() (*http.Response, error) { return legacy.Get(srv.URL, nil, nil) },
+			func() (*http.Response, error) { return c.Get(srv.URL) }},
+		{"Post", func() (*http.Response, error) { return legacy.Post(srv.URL, headers, params, body) },
+			func() (*http.Response, error) {
+				return c.Post(srv.URL, WithHeaders(headers), WithParams(params), WithBody(body))
+			}},
+		{"Post without body", func() (*http.Response, error) { return legacy.Post(srv.URL, nil, nil, nil) },
+			func() (*http.Response, error) { return c.Post(srv.URL) }},
+		{"Put", func() (*http.Response, error) { return legacy.Put(srv.URL, headers, nil, body) },
+			func() (*http.Response, error) { return c.Put(srv.URL, WithHeaders(headers), WithBody(body)) }},
+		{"Patch", func() (*http.Response, error) { return legacy.Patch(srv.URL, nil, params, body) },
+			func() (*http.Response, error) { return c.Patch(srv.URL, WithParams(params), WithBody(body)) }},
+		{"Delete", func() (*http.Response, error) { return legacy.Delete(srv.URL, headers, params) },
+			func() (*http.Response, error) { return c.Delete(srv.URL, WithHeaders(headers), WithParams(params)) }},
+		{"DeleteWithBody", func() (*http.Response, error) { return legacy.DeleteWithBody(srv.URL, nil, nil, body) },
+			func() (*http.Response, error) { return c.Delete(srv.URL, WithBody(body), WithBodyAllowed()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotBody, _ := readBodyWithStatus(got)
+
+			want, err := tt.opts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantBody, _ := readBodyWithStatus(want)
+
+			// The server sees the same request from both shapes
+			for _, k := range []string{"X-Method", "X-Query", "X-Token", "X-Content-Type"} {
+				if got.Header.Get(k) != want.Header.Get(k) {
+					t.Errorf("%s = %q, want %q", k, got.Header.Get(k), want.Header.Get(k))
+				}
+			}
+			if string(gotBody) != string(wantBody) {
+				t.Errorf("body = %q, want %q", gotBody, wantBody)
+			}
+		})
+	}
+
+	// Sanity check that the comparison covers real values
+	res, err := legacy.Post(srv.URL, headers, params, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := readBodyWithStatus(res)
+	if res.Header.Get("X-Query") != "limit=10" || res.Header.Get("X-Token") != "t0k3n" || string(got) != `{"name":"John"}` {
+		t.Errorf("server got %q %q %q", res.Header.Get("X-Query"), res.Header.Get("X-Token"), got)
+	}
+}
@@ -0,0 +1,184 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// defaultMaxLineBytes bounds the lines read by JSONLines and ReadEvents
+// unless WithMaxLineBytes sets another limit.
+const defaultMaxLineBytes = 1 << 20
+
+// maxLineBytes returns the line size limit of the request.
+func (ro *readOptions) maxLineBytes() int {
+	if ro.maxLine > 0 {
+		return ro.maxLine
+	}
+	return defaultMaxLineBytes
+}
+
+// lineScanner returns a scanner over r for lines of at most maxLine bytes.
+func lineScanner(r io.Reader, maxLine int) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	// The initial buffer must not exceed the limit, or it raises the limit
+	scanner.Buffer(make([]byte, 0, min(4096, maxLine)), maxLine)
+	return scanner
+}
+
+// lineError names the limit when err reports an overlong line.
+func lineError(err error, maxLine int) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("line exceeds %d bytes: %w", maxLine, err)
+	}
+	return err
+}
+
+// JSONLines decodes a newline-delimited JSON (NDJSON, JSON Lines) body into
+// one T per line, lazily as the body arrives. Blank lines are skipped and
+// CRLF line endings are accepted. A line that fails to decode yields an
+// error and iteration continues with the next line unless the loop stops.
+//
+// Non-2xx responses yield a single HttpError. Lines are limited to 1 MB
+// unless the request was sent with WithMaxLineBytes; a longer line ends the
+// iteration with an error. The body is closed when the iteration ends,
+// including when the loop breaks early. The body is streamed, so other
+// helpers return ErrBodyAlreadyConsumed afterwards.
+//
+// Example:
+//
+//	res, err := client.Get("https://api.com/export")
+//	if err != nil {
+//	    return err
+//	}
+//	for record, err := range httpx.JSONLines[Record](res) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(record)
+//	}
+func JSONLines[T any](res *http.Response) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		ro := readOptionsFor(res)
+
+		err := scanJSONLines(context.Background(), res, func(n int, line []byte) bool {
+			var out T
+			if err := ro.unmarshalJSON(line, &out); err != nil {
+				return yield(zero, fmt.Errorf("httpx: failed to decode JSON line %d: %w", n, err))
+			}
+			return yield(out, nil)
+		})
+		if err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// JSONLinesWithErrors decodes a newline-delimited JSON body whose lines are
+// either data or error objects, e.g. {"error": ...} entries in a bulk
+// export. isError routes each non-blank line: error lines are decoded as E
+// and appended to *errs as they arrive, all other lines are decoded as T and
+// yielded. Everything else works like JSONLines.
+//
+// Iteration stops with ctx.Err() once ctx is cancelled; a nil ctx is never
+// cancelled.
+//
+// Example:
+//
+//	var failures []BulkError
+//	isError := func(line []byte) bool { return bytes.HasPrefix(line, []byte(`{"error"`)) }
+//	for item, err := range httpx.JSONLinesWithErrors[Item](ctx, res, isError, &failures) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    index(item)
+//	}
+//	log.Printf("%d items failed", len(failures))
+func JSONLinesWithErrors[T, E any](ctx context.Context, res *http.Response, isError func(line []byte) bool, errs *[]E) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		ro := readOptionsFor(res)
+
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		err := scanJSONLines(ctx, res, func(n int, line []byte) bool {
+			if isError(line) {
+				var lineErr E
+				if err := ro.unmarshalJSON(line, &lineErr); err != nil {
+					return yield(zero, fmt.Errorf("httpx: failed to decode JSON error line %d: %w", n, err))
+				}
+				*errs = append(*errs, lineErr)
+				return true
+			}
+
+			var out T
+			if err := ro.unmarshalJSON(line, &out); err != nil {
+				return yield(zero, fmt.Errorf("httpx: failed to decode JSON line %d: %w", n, err))
+			}
+			return yield(out, nil)
+		})
+		if err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// scanJSONLines streams the non-blank lines of res to each, with their line
+// number, until each returns false, the body ends or ctx is done. It returns
+// an HttpError for non-2xx responses and read or context errors; the body
+// is always closed.
+func scanJSONLines(ctx context.Context, res *http.Response, each func(n int, line []byte) bool) error {
+	ro := readOptionsFor(res)
+	if ro.failed(res.StatusCode) {
+		_, err := readBodyWithStatus(res)
+		return err
+	}
+
+	defer res.Body.Close()
+
+	body, err := streamBody(res)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Unblock a pending read once ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		body.Close()
+		res.Body.Close()
+	})
+	defer stop()
+
+	maxLine := ro.maxLineBytes()
+	scanner := lineScanner(body, maxLine)
+
+	for n := 1; scanner.Scan(); n++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !each(n, line) {
+			return nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("httpx: reading JSON lines: %w", lineError(err, maxLine))
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type lineItem struct {
+	ID int `json:"id"`
+}
+
+type lineFailure struct {
+	Error string `json:"error"`
+	ID    int    `json:"id"`
+}
+
+func isErrorLine(line []byte) bool { return bytes.HasPrefix(line, []byte(`{"error"`)) }
+
+func TestJSONLines(t *testing.T) {
+	srv := contentServer(t, "application/x-ndjson", "{\"id\":1}\r\n\r\n{\"id\":2}\n\n\n{\"id\":3}\r\n")
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// CRLF endings are accepted and blank lines skipped
+	var ids []int
+	for item, err := range JSONLines[lineItem](res) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.ID)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(ids, want) {
+		t.Errorf("items = %v, want %v", ids, want)
+	}
+}
+
+func TestJSONLinesErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("{\"id\":1}\n"))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The status fails up front, before any line is decoded
+	var errs []error
+	for item, err := range JSONLines[lineItem](res) {
+		if err == nil {
+			t.Errorf("item %+v yielded from a 503", item)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	var httpErr *HttpError
+	if len(errs) != 1 || !errors.As(errs[0], &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("errors = %v, want a single 503 HttpError", errs)
+	}
+}
+
+func TestJSONLinesBreakClosesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"id\":1}\n{\"id\":2}\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // more lines would follow
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithNoTimeout())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := &closeCounter{ReadCloser: res.Body}
+	res.Body = body
+
+	for item, err := range JSONLines[lineItem](res) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.ID == 1 {
+			break
+		}
+	}
+	if n := body.closes.Load(); n != 1 {
+		t.Errorf("body closed %d times after break, want 1", n)
+	}
+}
+
+func TestJSONLinesMaxLineBytes(t *testing.T) {
+	srv := contentServer(t, "application/x-ndjson", "{\"id\":1}\n{\"id\":2,\"padding\":\""+strings.Repeat("x", 64)+"\"}\n{\"id\":3}\n")
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithMaxLineBytes(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The overlong line ends the iteration with an error naming the limit
+	var ids []int
+	var errs []error
+	for item, err := range JSONLines[lineItem](res) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, item.ID)
+	}
+	if want := []int{1}; !slices.Equal(ids, want) {
+		t.Errorf("items = %v, want %v", ids, want)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], bufio.ErrTooLong) || !strings.Contains(errs[0].Error(), "32 bytes") {
+		t.Errorf("errors = %v, want one ErrTooLong naming the 32 byte limit", errs)
+	}
+}
+
+func TestJSONLinesWithErrors(t *testing.T) {
+	body := strings.Join([]string{
+		`{"id":1}`,
+		`{"error":"conflict","id":2}`,
+		``,
+		`{"id":3}`,
+		`{"error":"invalid","id":4}`,
+		`{"error":"invalid","id":5}`,
+		`{"id":6}`,
+	}, "\r\n")
+	srv := contentServer(t, "application/x-ndjson", body)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failures []lineFailure
+	var ids []int
+	for item, err := range JSONLinesWithErrors[lineItem](context.Background(), res, isErrorLine, &failures) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Error lines are collected as they arrive, in order with the data
+		if want := map[int]int{1: 0, 3: 1, 6: 3}[item.ID]; len(failures) != want {
+			t.Errorf("item %d: %d failures collected, want %d", item.ID, len(failures), want)
+		}
+		ids = append(ids, item.ID)
+	}
+
+	if want := []int{1, 3, 6}; !slices.Equal(ids, want) {
+		t.Errorf("items = %v, want %v", ids, want)
+	}
+	want := []lineFailure{{"conflict", 2}, {"invalid", 4}, {"invalid", 5}}
+	if len(failures) != len(want) {
+		t.Fatalf("failures = %+v, want %+v", failures, want)
+	}
+	for i := range want {
+		if failures[i] != want[i] {
+			t.Errorf("failure %d = %+v, want %+v", i, failures[i], want[i])
+		}
+	}
+}
+
+func TestJSONLinesWithErrorsDecodeErrors(t *testing.T) {
+	srv := contentServer(t, "application/x-ndjson", "{\"id\":1}\n{\"error\":42}\n{\"id\":\"x\"}\n{\"id\":4}\n")
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Undecodable lines yield an error naming the line and iteration goes on
+	var failures []lineFailure
+	var got []string
+	for item, err := range JSONLinesWithErrors[lineItem](nil, res, isErrorLine, &failures) {
+		if err != nil {
+			got = append(got, err.Error())
+			continue
+		}
+		got = append(got, strings.Repeat("#", item.ID))
+	}
+
+	if len(got) != 4 || got[0] != "#" || !strings.Contains(got[1], "error line 2") ||
+		!strings.Contains(got[2], "JSON line 3") || got[3] != "####" {
+		t.Errorf("yielded %q", got)
+	}
+	if len(failures) != 0 {
+		t.Errorf("failures = %+v", failures)
+	}
+}
+
+func TestJSONLinesWithErrorsCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"id\":1}\n{\"error\":\"slow\",\"id\":2}\n{\"id\":3}\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // the stream stalls
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failures []lineFailure
+	var items int
+	var last error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, err := range JSONLinesWithErrors[lineItem](ctx, res, isErrorLine, &failures) {
+			if err != nil {
+				last = err
+				continue
+			}
+			if items++; items == 2 {
+				cancel() // while the next read is pending
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("iteration did not stop after cancel")
+	}
+	if !errors.Is(last, context.Canceled) || items != 2 || len(failures) != 1 {
+		t.Errorf("err = %v after %d items and %d failures", last, items, len(failures))
+	}
+}
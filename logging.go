@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// logExchange logs the outcome of req to Config.Logger: responses at info
+// level, or the level of the request's profile, with their status; failures
+// at error level. The response body is not touched.
+func (c *client) logExchange(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", LogicalMethod(req)),
+		slog.String("url", req.URL.Redacted()),
+		slog.Duration("elapsed", elapsed),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		c.Logger.LogAttrs(req.Context(), slog.LevelError, "httpx request failed", attrs...)
+		return
+	}
+
+	level := slog.LevelInfo
+	if ro, ok := req.Context().Value(readOptionsKey{}).(*readOptions); ok {
+		level = ro.logLevel
+	}
+
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	c.Logger.LogAttrs(req.Context(), level, "httpx request", attrs...)
+}
@@ -0,0 +1,96 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Probe is a startup check run by NewManaged before the client is handed
+// out, e.g. against a health endpoint of the API.
+type Probe struct {
+	// URL is requested with GET. Relative URLs are resolved against
+	// Config.BaseURL; an empty URL probes the base URL itself.
+	URL string
+
+	// Timeout bounds the probe. Defaults to 5 seconds.
+	Timeout time.Duration
+
+	// Tolerate lets NewManaged succeed although the probe failed. The
+	// failure is logged to Config.Logger, if set.
+	Tolerate bool
+}
+
+// timeout returns the configured or default probe timeout.
+func (p Probe) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return 5 * time.Second
+}
+
+// run sends the probe through c. Transport errors and non-2xx responses
+// fail the probe.
+func (p Probe) run(ctx context.Context, c Client) error {
+	res, err := c.Get(p.URL, WithContext(ctx), WithTimeout(p.timeout()))
+	if err != nil {
+		return err
+	}
+	_, err = readBodyWithStatus(res)
+	return err
+}
+
+// NewManaged builds a client from cfg for dependency injection frameworks
+// and lifecycle hooks. It runs the probes in order, bounded by ctx, and
+// returns the client together with its Close method as shutdown function.
+// Every probe outcome is reported to Config.Metrics, if set, next to the
+// request metrics of the client itself.
+//
+// A probe that fails without Tolerate closes the client again and fails
+// NewManaged with an error naming the probe.
+//
+// Example:
+//
+//	client, shutdown, err := httpx.NewManaged(ctx, &httpx.Config{
+//	    BaseURL: "https://api.example.com",
+//	}, httpx.Probe{URL: "/healthz", Timeout: 2 * time.Second})
+//	if err != nil {
+//	    return err
+//	}
+//	lc.Append(fx.Hook{OnStop: shutdown})
+func NewManaged(ctx context.Context, cfg *Config, probes ...Probe) (Client, func(context.Context) error, error) {
+	c, err := NewClient(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, probe := range probes {
+		started := time.Now()
+		err := probe.run(ctx, c)
+		if cfg != nil && cfg.Metrics != nil {
+			cfg.Metrics.ObserveProbe(ProbeMetric{
+				URL:       probe.URL,
+				Duration:  time.Since(started),
+				Err:       err,
+				Tolerated: err != nil && probe.Tolerate,
+			})
+		}
+		if err == nil {
+			continue
+		}
+
+		if probe.Tolerate {
+			if cfg != nil && cfg.Logger != nil {
+				cfg.Logger.LogAttrs(ctx, slog.LevelWarn, "httpx startup probe failed",
+					slog.String("url", probe.URL), slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		c.Close(context.Background())
+		return nil, nil, fmt.Errorf("httpx: startup probe %q: %w", probe.URL, err)
+	}
+
+	return c, c.Close, nil
+}
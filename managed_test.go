@@ -0,0 +1,93 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// healthServer answers /healthz with 200, /slow after the client gave up,
+// and everything else with 503.
+func healthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewManaged(t *testing.T) {
+	srv := healthServer(t)
+	metrics := newRecordingMetrics()
+
+	c, shutdown, err := NewManaged(context.Background(), &Config{BaseURL: srv.URL, Metrics: metrics},
+		Probe{URL: "/healthz"},
+		Probe{URL: "/down", Tolerate: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil {
+		t.Fatal("NewManaged returned no client")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if len(metrics.probes) != 2 {
+		t.Fatalf("got %d probe observations, want 2", len(metrics.probes))
+	}
+	if p := metrics.probes[0]; p.URL != "/healthz" || p.Err != nil || p.Tolerated || p.Duration <= 0 {
+		t.Errorf("healthy probe observed as %+v", p)
+	}
+	if p := metrics.probes[1]; p.URL != "/down" || p.Err == nil || !p.Tolerated {
+		t.Errorf("tolerated probe observed as %+v", p)
+	}
+
+	// The probes are requests of the client, too
+	if len(metrics.requests) != 2 {
+		t.Errorf("got %d request observations, want 2", len(metrics.requests))
+	}
+}
+
+func TestNewManagedProbeFailure(t *testing.T) {
+	srv := healthServer(t)
+
+	tests := map[string]Probe{
+		"error status": {URL: "/down"},
+		"timeout":      {URL: "/slow", Timeout: 50 * time.Millisecond},
+	}
+	for name, probe := range tests {
+		t.Run(name, func(t *testing.T) {
+			metrics := newRecordingMetrics()
+
+			c, shutdown, err := NewManaged(context.Background(), &Config{BaseURL: srv.URL, Metrics: metrics}, probe)
+			if err == nil || !strings.Contains(err.Error(), probe.URL) {
+				t.Fatalf("err = %v, want a failed probe", err)
+			}
+			if c != nil || shutdown != nil {
+				t.Error("failed NewManaged returned a client")
+			}
+
+			metrics.mu.Lock()
+			defer metrics.mu.Unlock()
+			if len(metrics.probes) != 1 || metrics.probes[0].Err == nil || metrics.probes[0].Tolerated {
+				t.Errorf("probes observed as %+v", metrics.probes)
+			}
+		})
+	}
+}
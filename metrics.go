@@ -0,0 +1,72 @@
+package httpx
+
+import (
+	"net/http"
+	"time"
+)
+
+// Metrics receives measurements of a client, e.g. to feed Prometheus or
+// OpenTelemetry instruments without httpx depending on either. Methods are
+// called synchronously from concurrent requests, so implementations must be
+// safe for concurrent use and cheap, such as atomic counters. Embed
+// NopMetrics to implement only some of the methods.
+type Metrics interface {
+	// ObserveRequest is called once per request, when the response headers
+	// arrived or the request failed.
+	ObserveRequest(RequestMetric)
+
+	// AddTraffic is called as wire bytes are written to and read from host,
+	// with the same accounting as Client.Traffic.
+	AddTraffic(host string, sent, received int64)
+
+	// ObserveProbe is called by NewManaged with the outcome of every
+	// startup probe.
+	ObserveProbe(ProbeMetric)
+}
+
+// RequestMetric describes the outcome of a single request.
+type RequestMetric struct {
+	Method     string        // Logical method, see LogicalMethod
+	Host       string        // Request host (host[:port])
+	StatusCode int           // Response status, 0 if Err is set
+	Duration   time.Duration // Time until the response headers or the failure
+	Err        error         // Error of the request, nil on success
+}
+
+// ProbeMetric describes the outcome of a NewManaged startup probe.
+type ProbeMetric struct {
+	URL       string        // Probe URL as configured
+	Duration  time.Duration // Time the probe took
+	Err       error         // Error of the probe, nil on success
+	Tolerated bool          // Whether a failure was tolerated
+}
+
+// NopMetrics implements Metrics by discarding everything.
+type NopMetrics struct{}
+
+// ObserveRequest implements Metrics.
+func (NopMetrics) ObserveRequest(RequestMetric) {}
+
+// AddTraffic implements Metrics.
+func (NopMetrics) AddTraffic(string, int64, int64) {}
+
+// ObserveProbe implements Metrics.
+func (NopMetrics) ObserveProbe(ProbeMetric) {}
+
+// reportExchange reports the outcome of req to Config.Metrics and logs it.
+func (c *client) reportExchange(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+	if c.Metrics != nil {
+		m := RequestMetric{
+			Method:   LogicalMethod(req),
+			Host:     req.URL.Host,
+			Duration: elapsed,
+			Err:      err,
+		}
+		if res != nil {
+			m.StatusCode = res.StatusCode
+		}
+		c.Metrics.ObserveRequest(m)
+	}
+
+	c.logExchange(req, res, err, elapsed)
+}
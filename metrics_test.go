@@ -0,0 +1,160 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingMetrics records everything it receives.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []RequestMetric
+	probes   []ProbeMetric
+	sent     map[string]int64
+	received map[string]int64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{sent: map[string]int64{}, received: map[string]int64{}}
+}
+
+func (m *recordingMetrics) ObserveRequest(r RequestMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, r)
+}
+
+func (m *recordingMetrics) ObserveProbe(p ProbeMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probes = append(m.probes, p)
+}
+
+func (m *recordingMetrics) AddTraffic(host string, sent, received int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[host] += sent
+	m.received[host] += received
+}
+
+func TestMetricsTraffic(t *testing.T) {
+	payload := strings.Repeat("compressible ", 1000)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(payload))
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := New(&Config{Metrics: metrics})
+	defer c.Close(context.Background())
+
+	res, err := c.Post(srv.URL, WithBody(map[string]string{"name": "John"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != payload {
+		t.Fatalf("body was not decoded")
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	stats := c.Traffic()
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if got, want := metrics.sent[host], stats.Hosts[host].BytesSent; got != want || got == 0 {
+		t.Errorf("metrics saw %d bytes sent, Traffic %d", got, want)
+	}
+	if got, want := metrics.received[host], stats.Hosts[host].BytesReceived; got != want || got == 0 {
+		t.Errorf("metrics saw %d bytes received, Traffic %d", got, want)
+	}
+
+	// Wire bytes, not decoded bytes
+	if got := stats.BytesReceived; got < int64(compressed.Len()) || got >= int64(len(payload)) {
+		t.Errorf("received %d bytes for a %d byte gzip body of %d bytes", got, compressed.Len(), len(payload))
+	}
+}
+
+func TestMetricsRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	metrics := newRecordingMetrics()
+	c := New(&Config{Metrics: metrics})
+	defer c.Close(context.Background())
+
+	res, err := c.Delete(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// A failing request is reported with its error
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if _, err := c.Get(closed.URL); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if len(metrics.requests) != 2 {
+		t.Fatalf("got %d observations, want 2", len(metrics.requests))
+	}
+
+	ok, failed := metrics.requests[0], metrics.requests[1]
+	if ok.Method != http.MethodDelete || ok.Host != strings.TrimPrefix(srv.URL, "http://") ||
+		ok.StatusCode != http.StatusTeapot || ok.Err != nil || ok.Duration <= 0 {
+		t.Errorf("success observed as %+v", ok)
+	}
+	if failed.Method != http.MethodGet || failed.StatusCode != 0 || failed.Err == nil {
+		t.Errorf("failure observed as %+v", failed)
+	}
+}
+
+// statusMetrics only observes requests, leaving traffic to NopMetrics.
+type statusMetrics struct {
+	NopMetrics
+	statuses chan int
+}
+
+func (m statusMetrics) ObserveRequest(r RequestMetric) { m.statuses <- r.StatusCode }
+
+func TestNopMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	metrics := statusMetrics{statuses: make(chan int, 1)}
+	c := New(&Config{Metrics: metrics})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := <-metrics.statuses; got != http.StatusOK {
+		t.Errorf("observed status %d", got)
+	}
+}
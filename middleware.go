@@ -0,0 +1,33 @@
+package httpx
+
+import "net/http"
+
+// Middleware wraps the transport of a client to add cross-cutting behavior
+// such as tracing, metrics or authentication. It receives the next
+// http.RoundTripper in the chain and returns the one to call instead.
+//
+// Middlewares see every round trip, including retries and redirects. They
+// run outside the traffic counters, so headers they add are counted, and
+// they receive gzip responses already decoded.
+//
+// Example:
+//
+//	timing := func(next http.RoundTripper) http.RoundTripper {
+//	    return httpx.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+//	        start := time.Now()
+//	        res, err := next.RoundTrip(req)
+//	        metrics.Observe(req.URL.Host, time.Since(start))
+//	        return res, err
+//	    })
+//	}
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// chainMiddlewares wraps base so that middlewares[0] is the outermost
+// layer: requests pass through the middlewares in order, responses in
+// reverse order.
+func chainMiddlewares(base http.RoundTripper, middlewares []Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		base = middlewares[i](base)
+	}
+	return base
+}
@@ -0,0 +1,88 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// callLog records the order in which middlewares see round trips.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(s string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, s)
+}
+
+func (l *callLog) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	calls := l.calls
+	l.calls = nil
+	return calls
+}
+
+// recording returns a middleware that logs name before and after the rest
+// of the chain.
+func (l *callLog) recording(name string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			l.add(name + " " + req.URL.Path)
+			res, err := next.RoundTrip(req)
+			l.add("/" + name)
+			return res, err
+		})
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var log callLog
+	c := New(&Config{
+		Middlewares: []Middleware{log.recording("outer"), log.recording("middle"), log.recording("inner")},
+		Retry:       &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond},
+	})
+	defer c.Close(context.Background())
+
+	// Requests pass in order, responses in reverse order
+	res, err := c.Get(redirectChain(t).URL + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := log.take(), []string{"outer /0", "middle /0", "inner /0", "/inner", "/middle", "/outer"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+
+	// Every redirect hop runs the whole chain
+	res, err = c.Get(redirectChain(t).URL + "/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := log.take(), []string{
+		"outer /1", "middle /1", "inner /1", "/inner", "/middle", "/outer",
+		"outer /0", "middle /0", "inner /0", "/inner", "/middle", "/outer",
+	}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+
+	// So does every retry
+	res, err = c.Get(flakyServer(t, 1).URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got, want := log.take(), []string{
+		"outer /", "middle /", "inner /", "/inner", "/middle", "/outer",
+		"outer /", "middle /", "inner /", "/inner", "/middle", "/outer",
+	}; !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
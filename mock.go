@@ -0,0 +1,68 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// RoundTripFunc adapts an ordinary function to the http.RoundTripper
+// interface. It is mostly useful in tests to stub out network access.
+//
+// Example:
+//
+//	rt := httpx.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+//	    return &http.Response{StatusCode: 204, Body: http.NoBody, Request: req}, nil
+//	})
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// HandlerTransport returns an http.RoundTripper that serves every request
+// in-process with the given handler instead of sending it over the network.
+// The handler sees the outgoing request exactly as httpx built it.
+func HandlerTransport(handler http.Handler) http.RoundTripper {
+	return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// Server-side handlers expect a non-nil body
+		in := req
+		if in.Body == nil {
+			in = req.Clone(req.Context())
+			in.Body = http.NoBody
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, in)
+
+		res := rec.Result()
+		res.Request = req
+		return res, nil
+	})
+}
+
+// NewMockClient constructs an httpx client whose requests are routed to the
+// given handler instead of the network. Everything else behaves like a client
+// created with New, so headers, params, body encoding, retries and response
+// helpers can be exercised in unit tests without an httptest server.
+//
+// When cfg is nil, all defaults are applied.
+//
+// Example:
+//
+//	client := httpx.NewMockClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	    w.Header().Set("Content-Type", "application/json")
+//	    w.Write([]byte(`{"firstname":"John"}`))
+//	}), nil)
+//
+//	res, _ := client.Get("https://api.com/users/1")
+//	user, err := httpx.JSON[User](res)
+func NewMockClient(handler http.Handler, cfg *Config) Client {
+	mockCfg := Config{}
+	if cfg != nil {
+		mockCfg = *cfg
+	}
+	mockCfg.Transport = HandlerTransport(handler)
+
+	return New(&mockCfg)
+}
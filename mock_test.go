@@ -0,0 +1,111 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewMockClient(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"name":"`+r.Header.Get("X-Team")+" "+r.URL.Query().Get("page")+" "+string(body)+`"}`)
+	})
+
+	cfg := &Config{
+		BaseURL: "https://api.test",
+		Headers: http.Header{"X-Team": {"core"}},
+		Retry:   &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond},
+	}
+	c := NewMockClient(handler, cfg)
+	defer c.Close(context.Background())
+
+	// The handler sees the request as built from the config and options,
+	// retries included
+	res, err := c.Put("/users", WithParams(map[string]string{"page": "2"}), WithBody("ada"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := JSON[decodedUser](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "core 2 ada" {
+		t.Errorf("name = %q, want %q", user.Name, "core 2 ada")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d calls, want 2", n)
+	}
+
+	// The caller's config keeps its transport
+	if cfg.Transport != nil {
+		t.Error("NewMockClient modified the config")
+	}
+}
+
+func TestHandlerTransport(t *testing.T) {
+	rt := HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers may read the body even if the request had none
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("X-Path", r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.test/jobs", nil)
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusAccepted || res.Header.Get("X-Path") != "/jobs" {
+		t.Errorf("got %d with path %q", res.StatusCode, res.Header.Get("X-Path"))
+	}
+	if res.Request != req {
+		t.Error("response does not point to the original request")
+	}
+	if req.Body != nil {
+		t.Error("the original request was modified")
+	}
+}
+
+func TestRoundTripFunc(t *testing.T) {
+	offline := errors.New("offline")
+	c := New(&Config{Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "down.test" {
+			return nil, offline
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(req.Method + " " + req.URL.Path)),
+			Request:    req,
+		}, nil
+	})})
+	defer c.Close(context.Background())
+
+	res, err := c.Delete("https://api.test/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, err := c.(*client).Text(res); err != nil || text != "DELETE /users/1" {
+		t.Errorf("Text() = %q, %v", text, err)
+	}
+
+	if _, err := c.Get("https://down.test/"); !errors.Is(err, offline) {
+		t.Errorf("err = %v, want the transport's error", err)
+	}
+}
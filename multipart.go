@@ -0,0 +1,225 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MultipartFile is a file part of a streamed multipart/form-data body.
+type MultipartFile struct {
+	Name        string    // Form field name
+	Filename    string    // File name reported to the server
+	ContentType string    // MIME type, defaults to application/octet-stream
+	Reader      io.Reader // File content, streamed and never buffered fully
+}
+
+// MultipartForm is a multipart/form-data body set via WithMultipart.
+//
+// The encoding is byte-stable for equal input: fields in sorted key order,
+// then files in order, so bodies can be signed. Combine it with
+// WithMultipartBoundary when the signature also covers the boundary.
+type MultipartForm struct {
+	Fields map[string]string // Plain form fields, written in sorted key order
+	Files  []MultipartFile   // File parts, written after the fields in order
+}
+
+// FilePart is a file value inside a map[string]any multipart body. Unlike a
+// plain []byte value, it controls the reported file name and content type,
+// and its content is streamed instead of being loaded into memory.
+//
+// Example:
+//
+//	client.Post(url,
+//	    httpx.WithBody(map[string]any{
+//	        "username": "John",
+//	        "avatar":   httpx.FilePart{Filename: "me.png", ContentType: "image/png", Content: f},
+//	    }),
+//	    httpx.WithHeaders(http.Header{"Content-Type": []string{"multipart/form-data"}}),
+//	)
+type FilePart struct {
+	Filename    string    // File name reported to the server
+	ContentType string    // MIME type, defaults to application/octet-stream
+	Content     io.Reader // File content
+}
+
+// multipartFromMap converts a map[string]any multipart body into a form.
+// Strings become fields, []byte values become files named after their key,
+// and FilePart values become streamed files. The streamed result reports
+// whether any FilePart was found. Files are ordered by key.
+func multipartFromMap(values map[string]any) (*MultipartForm, bool, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	form := &MultipartForm{Fields: make(map[string]string)}
+	streamed := false
+
+	for _, key := range keys {
+		switch cast := values[key].(type) {
+
+		case string:
+			// form field value
+			form.Fields[key] = cast
+
+		case []byte:
+			// file upload (raw bytes)
+			form.Files = append(form.Files, MultipartFile{Name: key, Filename: key, Reader: bytes.NewReader(cast)})
+
+		case FilePart:
+			form.Files = append(form.Files, MultipartFile{Name: key, Filename: cast.Filename, ContentType: cast.ContentType, Reader: cast.Content})
+			streamed = true
+
+		case *FilePart:
+			if cast == nil {
+				return nil, false, fmt.Errorf("nil *FilePart for key %s", key)
+			}
+			form.Files = append(form.Files, MultipartFile{Name: key, Filename: cast.Filename, ContentType: cast.ContentType, Reader: cast.Content})
+			streamed = true
+
+		default:
+			return nil, false, fmt.Errorf("unsupported multipart field type %T for key %s", cast, key)
+		}
+	}
+
+	return form, streamed, nil
+}
+
+// quoteEscaper escapes values placed inside quoted header parameters.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartStream is a lazily started pipe carrying an encoded multipart
+// body. The encoder goroutine only starts on the first Read, so requests that
+// are aborted before sending never spawn it, and closing the stream stops an
+// encoder that is blocked on the pipe.
+type multipartStream struct {
+	once  sync.Once
+	pr    *io.PipeReader
+	start func()
+}
+
+func (m *multipartStream) Read(p []byte) (int, error) {
+	m.once.Do(m.start)
+	return m.pr.Read(p)
+}
+
+func (m *multipartStream) Close() error {
+	return m.pr.Close()
+}
+
+// newMultipartStream returns a streaming body for form together with its
+// boundary. The encoder runs as a tracked client task and stops when ctx is
+// done. An empty boundary is chosen at random.
+func (c *client) newMultipartStream(ctx context.Context, form *MultipartForm, boundary string) (*multipartStream, string, error) {
+	pr, pw := io.Pipe()
+	mw, err := newMultipartWriter(pw, boundary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stream := &multipartStream{pr: pr}
+	stream.start = func() {
+		started := c.tasks.spawn("multipart encoder", func(taskCtx context.Context) {
+			// Closing the client unblocks an encoder stuck on the pipe
+			stop := context.AfterFunc(taskCtx, func() { pw.CloseWithError(ErrClientClosed) })
+			defer stop()
+
+			// So does the caller giving up on the request
+			stopCaller := context.AfterFunc(ctx, func() { pw.CloseWithError(ctx.Err()) })
+			defer stopCaller()
+
+			pw.CloseWithError(writeMultipart(mw, form))
+		})
+		if !started {
+			pw.CloseWithError(ErrClientClosed)
+		}
+	}
+
+	return stream, mw.Boundary(), nil
+}
+
+// multipartBoundary returns the boundary requested for a multipart body:
+// the one set via WithMultipartBoundary, else a boundary parameter of the
+// Content-Type the caller set, else "" for a random one.
+func multipartBoundary(fixed, contentType string) string {
+	if fixed != "" {
+		return fixed
+	}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		return params["boundary"]
+	}
+	return ""
+}
+
+// multipartContentType returns the Content-Type of a form written with
+// boundary. Parameters such as charset of a multipart/form-data
+// Content-Type the caller set are kept.
+func multipartContentType(contentType, boundary string) string {
+	params := make(map[string]string)
+	if mediaType, p, err := mime.ParseMediaType(contentType); err == nil && mediaType == "multipart/form-data" {
+		params = p
+	}
+	params["boundary"] = boundary
+
+	return mime.FormatMediaType("multipart/form-data", params)
+}
+
+// newMultipartWriter returns a multipart writer using boundary, or a random
+// boundary when it is empty.
+func newMultipartWriter(w io.Writer, boundary string) (*multipart.Writer, error) {
+	mw := multipart.NewWriter(w)
+	if boundary != "" {
+		if err := mw.SetBoundary(boundary); err != nil {
+			return nil, fmt.Errorf("httpx: invalid multipart boundary %q: %w", boundary, err)
+		}
+	}
+	return mw, nil
+}
+
+// writeMultipart encodes all fields in sorted key order followed by all
+// files in the given order, then writes the closing boundary.
+func writeMultipart(mw *multipart.Writer, form *MultipartForm) error {
+	keys := make([]string, 0, len(form.Fields))
+	for key := range form.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := mw.WriteField(key, form.Fields[key]); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range form.Files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(file.Name), quoteEscaper.Replace(file.Filename)))
+		h.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(part, file.Reader); err != nil {
+			return fmt.Errorf("httpx: streaming multipart file %q: %w", file.Name, err)
+		}
+	}
+
+	return mw.Close()
+}
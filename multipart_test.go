@@ -0,0 +1,165 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// bodyRecorder records the Content-Type and body of every request.
+func bodyRecorder(t *testing.T) (*httptest.Server, func() (string, []byte)) {
+	t.Helper()
+
+	var contentType string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() (string, []byte) { return contentType, body }
+}
+
+// checkGolden compares got with testdata/name, or rewrites it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("body differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestMultipartGolden(t *testing.T) {
+	srv, last := bodyRecorder(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	fields := map[string]string{"zeta": "z", "alpha": "a", "mid": "m \"quoted\""}
+	tests := map[string]func() []Option{
+		"WithMultipart": func() []Option {
+			return []Option{WithMultipart(fields,
+				MultipartFile{Name: "doc", Filename: "doc.txt", ContentType: "text/plain", Reader: strings.NewReader("first file")},
+				MultipartFile{Name: "raw", Filename: "raw.bin", Reader: strings.NewReader("second file")},
+			)}
+		},
+		"map body": func() []Option {
+			return []Option{
+				WithBody(map[string]any{
+					"zeta": "z", "alpha": "a", "mid": "m \"quoted\"",
+					"raw": FilePart{Filename: "raw.bin", Content: strings.NewReader("second file")},
+					"doc": FilePart{Filename: "doc.txt", ContentType: "text/plain", Content: strings.NewReader("first file")},
+				}),
+				WithHeaders(http.Header{"Content-Type": {"multipart/form-data"}}),
+			}
+		},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Every run produces the same bytes
+			var first []byte
+			for range 5 {
+				res, err := c.Post(srv.URL, append(opts(), WithMultipartBoundary("httpx-golden-boundary"))...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+
+				contentType, body := last()
+				if contentType != "multipart/form-data; boundary=httpx-golden-boundary" {
+					t.Errorf("Content-Type = %q", contentType)
+				}
+				if first != nil && !bytes.Equal(body, first) {
+					t.Fatalf("body changed between runs:\n%s\n%s", first, body)
+				}
+				first = body
+			}
+			checkGolden(t, "multipart.golden", first)
+		})
+	}
+}
+
+func TestMultipartBoundaryFromContentType(t *testing.T) {
+	srv, last := bodyRecorder(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Post(srv.URL,
+		WithMultipart(map[string]string{"a": "1"}),
+		WithHeaders(http.Header{"Content-Type": {"multipart/form-data; charset=utf-8; boundary=from-header"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	contentType, body := last()
+	if contentType != "multipart/form-data; boundary=from-header; charset=utf-8" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if !bytes.HasPrefix(body, []byte("--from-header\r\n")) {
+		t.Errorf("body starts with %q", body[:min(len(body), 20)])
+	}
+
+	// Invalid boundaries fail the request
+	for _, boundary := range []string{strings.Repeat("b", 71), "bad\tboundary"} {
+		if _, err := c.Post(srv.URL, WithMultipart(nil), WithMultipartBoundary(boundary)); err == nil {
+			t.Errorf("boundary %q accepted", boundary)
+		}
+	}
+}
+
+func TestFormGolden(t *testing.T) {
+	srv, last := bodyRecorder(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	type Form struct {
+		Zeta  string   `url:"zeta"`
+		Alpha string   `url:"alpha"`
+		Tags  []string `url:"tag"`
+	}
+	bodies := map[string]any{
+		"struct":     Form{Zeta: "z z", Alpha: "a&b", Tags: []string{"y", "x"}},
+		"url.Values": url.Values{"zeta": {"z z"}, "alpha": {"a&b"}, "tag": {"y", "x"}},
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			// Keys are sorted, repeated values keep their order
+			for range 5 {
+				res, err := c.Post(srv.URL, WithBody(body),
+					WithHeaders(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+
+				_, got := last()
+				checkGolden(t, "form.golden", got)
+			}
+		})
+	}
+}
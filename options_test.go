@@ -0,0 +1,270 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithIfMatch(t *testing.T) {
+	const current = `"v2"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-If-Match", r.Header.Get("If-Match"))
+		if match := r.Header.Get("If-Match"); match != current && match != "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		etag     string
+		sent     string
+		conflict bool
+	}{
+		{"v2", `"v2"`, false},
+		{`"v2"`, `"v2"`, false},
+		{"*", "*", false},
+		{"v1", `"v1"`, true},
+		{`W/"v2"`, `W/"v2"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.etag, func(t *testing.T) {
+			res, err := c.Put(srv.URL, WithIfMatch(tt.etag), WithBody(map[string]string{"name": "John"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.Header.Get("X-If-Match"); got != tt.sent {
+				t.Errorf("If-Match = %q, want %q", got, tt.sent)
+			}
+
+			_, err = readBodyWithStatus(res)
+			var httpErr *HttpError
+			if conflict := errors.As(err, &httpErr) && httpErr.IsPreconditionFailed(); conflict != tt.conflict {
+				t.Errorf("err = %v, want precondition failure %v", err, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestWithHeaderTransform(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, k := range []string{"Keep-Alive", "Proxy-Connection", "X-Global", "X-Request", "X-Renamed", "Content-Type"} {
+			w.Header()["Got-"+k] = r.Header.Values(k)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Headers: http.Header{"X-Global": {"global"}, "Proxy-Connection": {"keep-alive"}}})
+	defer c.Close(context.Background())
+
+	stripHopByHop := func(h http.Header) {
+		for _, k := range []string{"Keep-Alive", "Proxy-Connection"} {
+			h.Del(k)
+		}
+	}
+	var seen http.Header
+	rename := func(h http.Header) {
+		seen = h.Clone()
+		h.Set("X-Renamed", h.Get("X-Request"))
+		h.Del("X-Request")
+	}
+
+	res, err := c.Post(srv.URL,
+		WithBody(map[string]string{"name": "John"}),
+		WithHeaders(http.Header{"Keep-Alive": {"timeout=5"}, "X-Request": {"request"}}),
+		WithHeaderTransform(stripHopByHop),
+		WithHeaderTransform(rename),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// Transforms see the merged headers, in order
+	for k, want := range map[string]string{"X-Global": "global", "X-Request": "request", "Content-Type": "application/json"} {
+		if got := seen.Get(k); got != want {
+			t.Errorf("transform saw %s = %q, want %q", k, got, want)
+		}
+	}
+	if seen.Get("Keep-Alive") != "" {
+		t.Error("second transform ran before the first")
+	}
+
+	for k, want := range map[string]string{"Keep-Alive": "", "Proxy-Connection": "", "X-Request": "", "X-Renamed": "request", "X-Global": "global"} {
+		if got := res.Header.Get("Got-" + k); got != want {
+			t.Errorf("server got %s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestWithResponseHook(t *testing.T) {
+	srv := contentServer(t, "application/json", `{"name":"JOHN"}`)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	lower := func(res *http.Response) (*http.Response, error) {
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(bytes.ToLower(b)))
+		res.ContentLength = -1
+		return res, nil
+	}
+	fresh := func(res *http.Response) (*http.Response, error) {
+		res.Body.Close()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"name":"replaced"}`)),
+		}, nil
+	}
+	keep := func(res *http.Response) (*http.Response, error) { return nil, nil }
+
+	tests := []struct {
+		name string
+		hook ResponseHook
+		want string
+	}{
+		{"body rewritten", lower, "john"},
+		{"new response", fresh, "replaced"},
+		{"nil keeps the response", keep, "JOHN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(srv.URL, WithResponseHook(tt.hook))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The helpers read the replacement
+			user, err := JSON[struct{ Name string }](res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != tt.want {
+				t.Errorf("name = %q, want %q", user.Name, tt.want)
+			}
+			if res.Request == nil || res.Request.URL.String() != srv.URL {
+				t.Errorf("request = %v, want the original", res.Request)
+			}
+		})
+	}
+
+	// A hook error fails the request
+	errHook := errors.New("rejected")
+	_, err := c.Get(srv.URL, WithResponseHook(func(*http.Response) (*http.Response, error) { return nil, errHook }))
+	if !errors.Is(err, errHook) {
+		t.Errorf("err = %v, want the hook error", err)
+	}
+}
+
+func TestWithDisableKeepAlive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Remote", r.RemoteAddr)
+		w.Header().Set("X-Connection", r.Header.Get("Connection"))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	send := func(opts ...Option) (remote, connection string) {
+		t.Helper()
+		res, err := c.Get(srv.URL, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		readBodyWithStatus(res)
+		return res.Header.Get("X-Remote"), res.Header.Get("X-Connection")
+	}
+
+	first, _ := send()
+	pooled, _ := send()
+	if pooled != first {
+		t.Fatalf("second request dialed %s, want the pooled %s", pooled, first)
+	}
+
+	// The request itself may reuse the pool, but asks to close afterwards
+	closing, connection := send(WithDisableKeepAlive())
+	if closing != first || connection != "close" {
+		t.Errorf("request sent on %s with Connection %q", closing, connection)
+	}
+
+	next, connection := send()
+	if next == closing || connection != "" {
+		t.Errorf("next request sent on %s with Connection %q, want a new connection", next, connection)
+	}
+}
+
+func TestWithResponseValidatorChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/pending" {
+			w.Write([]byte(`{"id":"1","state":""}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1","state":"paid"}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	isJSON := func(res *http.Response) error {
+		order = append(order, "config")
+		if res.Header.Get("Content-Type") != "application/json" {
+			return errors.New("not JSON")
+		}
+		return nil
+	}
+	errNoState := errors.New("order without state")
+	hasState := func(res *http.Response) error {
+		order = append(order, "request")
+		o, err := JSON[struct{ State string }](res)
+		if err == nil && o.State == "" {
+			return errNoState
+		}
+		return err
+	}
+	never := func(res *http.Response) error {
+		order = append(order, "never")
+		return nil
+	}
+
+	c := New(&Config{ResponseValidators: []ResponseValidator{isJSON}})
+	defer c.Close(context.Background())
+
+	// The generic check passes; the endpoint-specific one catches the logical error
+	order = nil
+	_, err := c.Get(srv.URL+"/pending", WithResponseValidatorChain(hasState), WithResponseValidatorChain(never))
+	if !errors.Is(err, errNoState) {
+		t.Errorf("err = %v, want the second validator's", err)
+	}
+	if want := []string{"config", "request"}; !slices.Equal(order, want) {
+		t.Errorf("ran %v, want %v", order, want)
+	}
+
+	// All validators pass and the body is replayed to the caller
+	order = nil
+	res, err := c.Get(srv.URL, WithResponseValidatorChain(hasState, never))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := JSON[struct{ State string }](res)
+	if err != nil || o.State != "paid" {
+		t.Errorf("decoded %+v, %v", o, err)
+	}
+	if want := []string{"config", "request", "never"}; !slices.Equal(order, want) {
+		t.Errorf("ran %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,162 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// OrderedMap is a decoded JSON object that remembers the order of its keys
+// as they appeared in the source, e.g. for canonical JSON or signatures.
+//
+// Nested objects decode as *OrderedMap, arrays as []any, and numbers as
+// json.Number so that no precision is lost. A duplicate key keeps the
+// position of its first occurrence and the value of its last.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// Keys returns the keys in source order.
+func (m *OrderedMap) Keys() []string {
+	return append([]string(nil), m.keys...)
+}
+
+// Get returns the value stored under key.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *OrderedMap) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := v.(*OrderedMap)
+	if !ok {
+		return fmt.Errorf("httpx: expected JSON object, got %T", v)
+	}
+
+	*m = *obj
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the keys in their original
+// order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered reads the next JSON value from dec, decoding objects as
+// *OrderedMap.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		m := &OrderedMap{values: make(map[string]any)}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, seen := m.values[key]; !seen {
+				m.keys = append(m.keys, key)
+			}
+			m.values[key] = v
+		}
+		if _, err := dec.Token(); err != nil { // closing '}'
+			return nil, err
+		}
+		return m, nil
+
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := dec.Token(); err != nil { // closing ']'
+			return nil, err
+		}
+		return arr, nil
+	}
+
+	return tok, nil
+}
+
+// OrderedJSON decodes a JSON object response into an OrderedMap that keeps
+// the key order of the body. Non-2xx responses return an HttpError.
+//
+// Example:
+//
+//	obj, err := httpx.OrderedJSON(res)
+//	for _, key := range obj.Keys() {
+//	    v, _ := obj.Get(key)
+//	    fmt.Println(key, v)
+//	}
+func OrderedJSON(res *http.Response) (*OrderedMap, error) {
+	b, err := readBodyForDecode(res)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &OrderedMap{values: make(map[string]any)}
+	if len(b) == 0 {
+		return m, nil
+	}
+
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("httpx: failed to decode JSON: %w", err)
+	}
+
+	return m, nil
+}
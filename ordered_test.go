@@ -0,0 +1,81 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOrderedJSON(t *testing.T) {
+	const body = `{"zeta":1,"alpha":{"y":true,"b":null},"mid":[{"k2":"v","k1":"w"}],"big":12345678901234567890,"zeta":2}`
+	srv := contentServer(t, "application/json", body)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := OrderedJSON(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A duplicate key keeps its first position and its last value
+	if got := obj.Keys(); !reflect.DeepEqual(got, []string{"zeta", "alpha", "mid", "big"}) {
+		t.Errorf("keys = %q", got)
+	}
+	if v, _ := obj.Get("zeta"); v != json.Number("2") {
+		t.Errorf("zeta = %v, want the last value", v)
+	}
+	if v, _ := obj.Get("big"); v != json.Number("12345678901234567890") {
+		t.Errorf("big = %v, want the exact number", v)
+	}
+
+	alpha, _ := obj.Get("alpha")
+	if nested, ok := alpha.(*OrderedMap); !ok || !reflect.DeepEqual(nested.Keys(), []string{"y", "b"}) {
+		t.Errorf("alpha = %#v, want an ordered object", alpha)
+	}
+
+	// Marshalling writes the keys back in source order
+	out, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"zeta":2,"alpha":{"y":true,"b":null},"mid":[{"k2":"v","k1":"w"}],"big":12345678901234567890}`; string(out) != want {
+		t.Errorf("marshalled %s, want %s", out, want)
+	}
+}
+
+func TestOrderedJSONErrors(t *testing.T) {
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := map[string]string{
+		"array":     `[1,2]`,
+		"malformed": `{"a":`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := c.Get(contentServer(t, "application/json", body).URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := OrderedJSON(res); err == nil {
+				t.Error("decoded without error")
+			}
+		})
+	}
+
+	// An empty body is an empty object
+	res, err := c.Get(contentServer(t, "", "").URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := OrderedJSON(res)
+	if err != nil || obj.Len() != 0 {
+		t.Errorf("empty body: %v, %v", obj, err)
+	}
+}
@@ -0,0 +1,55 @@
+package httpx
+
+import "net/http"
+
+// MethodOverrideHeader carries the logical method of a request that was sent
+// as POST because Config.MethodOverride or WithMethodOverride is set.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// overrideMethod returns the method to send on the wire for the logical
+// method. PUT, PATCH and DELETE are tunneled through POST when override is
+// set; every other method is sent unchanged.
+func overrideMethod(method string, override bool) string {
+	if !override {
+		return method
+	}
+
+	switch method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return http.MethodPost
+	}
+	return method
+}
+
+// LogicalMethod returns the method a request stands for. For requests sent
+// through a method override this is the overridden method (e.g. PATCH)
+// rather than the POST on the wire, so interceptors can use it for logging,
+// metrics, or signing schemes that cover the logical method.
+//
+// Example:
+//
+//	client.OnRequest(func(req *http.Request) error {
+//	    metrics.Inc(httpx.LogicalMethod(req), req.URL.Path)
+//	    return nil
+//	})
+func LogicalMethod(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	if ro, ok := req.Context().Value(readOptionsKey{}).(*readOptions); ok && ro.method != "" {
+		return ro.method
+	}
+	return req.Method
+}
+
+// sentAs returns the wire method of req when it differs from its logical
+// method, or "" otherwise.
+func sentAs(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	if method := LogicalMethod(req); method != req.Method {
+		return req.Method
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// overrideServer echoes the wire method and the override header, and fails
+// with 409 when the request path is /conflict.
+func overrideServer(t testing.TB) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Override", r.Header.Get(MethodOverrideHeader))
+		if r.URL.Path == "/conflict" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMethodOverride(t *testing.T) {
+	srv := overrideServer(t)
+
+	for _, tc := range []struct {
+		name   string
+		config bool
+		opts   []Option
+	}{
+		{"config", true, nil},
+		{"option", false, []Option{WithMethodOverride()}},
+	} {
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			t.Run(tc.name+"/"+method, func(t *testing.T) {
+				var (
+					mu          sync.Mutex
+					signed      []string
+					intercepted []string
+					curls       []string
+				)
+				metrics := newRecordingMetrics()
+
+				c := New(&Config{
+					MethodOverride: tc.config,
+					Metrics:        metrics,
+					Signer: signerFunc(func(req *http.Request, _ string) error {
+						mu.Lock()
+						defer mu.Unlock()
+						signed = append(signed, req.Method+" as "+LogicalMethod(req))
+						return nil
+					}),
+				})
+				defer c.Close(context.Background())
+				c.OnRequest(func(req *http.Request) error {
+					mu.Lock()
+					defer mu.Unlock()
+					intercepted = append(intercepted, LogicalMethod(req))
+					return nil
+				})
+
+				opts := append([]Option{WithCurlLog(func(cmd string) {
+					mu.Lock()
+					defer mu.Unlock()
+					curls = append(curls, cmd)
+				})}, tc.opts...)
+
+				res, err := c.Do(method, srv.URL+"/items", opts...)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				res.Body.Close()
+
+				// A POST goes on the wire with the logical method in the header
+				if got := res.Header.Get("X-Method"); got != http.MethodPost {
+					t.Errorf("wire method = %q, want POST", got)
+				}
+				if got := res.Header.Get("X-Override"); got != method {
+					t.Errorf("%s = %q, want %q", MethodOverrideHeader, got, method)
+				}
+				if got := LogicalMethod(res.Request); got != method {
+					t.Errorf("LogicalMethod = %q, want %q", got, method)
+				}
+
+				// A failing request reports the logical method, annotated
+				res, err = c.Do(method, srv.URL+"/conflict", opts...)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+				_, err = readBodyWithStatus(res)
+				var httpErr *HttpError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("err = %v, want *HttpError", err)
+				}
+				if httpErr.Method != method || httpErr.SentAs != http.MethodPost {
+					t.Errorf("HttpError method = %q sent as %q, want %q sent as POST", httpErr.Method, httpErr.SentAs, method)
+				}
+				if want := method + " (sent as POST) "; !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %q, want it to contain %q", err, want)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				// Interceptors, the signer and metrics see the logical method
+				for _, got := range intercepted {
+					if got != method {
+						t.Errorf("interceptor saw %q, want %q", got, method)
+					}
+				}
+				for _, got := range signed {
+					if want := "POST as " + method; got != want {
+						t.Errorf("signer saw %q, want %q", got, want)
+					}
+				}
+				metrics.mu.Lock()
+				for _, r := range metrics.requests {
+					if r.Method != method {
+						t.Errorf("metric method = %q, want %q", r.Method, method)
+					}
+				}
+				if len(metrics.requests) != 2 {
+					t.Errorf("%d metrics, want 2", len(metrics.requests))
+				}
+				metrics.mu.Unlock()
+
+				// The curl command reproduces the wire request with its header
+				for _, cmd := range curls {
+					if !strings.Contains(cmd, "-X POST") || !strings.Contains(cmd, http.CanonicalHeaderKey(MethodOverrideHeader)+": "+method) {
+						t.Errorf("curl = %q, want a POST carrying %s: %s", cmd, MethodOverrideHeader, method)
+					}
+				}
+				if len(signed) != 2 || len(intercepted) != 2 || len(curls) != 2 {
+					t.Errorf("signed %d, intercepted %d, rendered %d requests, want 2 each", len(signed), len(intercepted), len(curls))
+				}
+			})
+		}
+	}
+}
+
+func TestMethodOverrideLeavesOtherMethods(t *testing.T) {
+	srv := overrideServer(t)
+
+	c := New(&Config{MethodOverride: true})
+	defer c.Close(context.Background())
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodHead} {
+		res, err := c.Do(method, srv.URL+"/items")
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		res.Body.Close()
+
+		if got := res.Header.Get("X-Method"); got != method {
+			t.Errorf("%s sent as %q", method, got)
+		}
+		if got := res.Header.Get("X-Override"); got != "" {
+			t.Errorf("%s carries %s %q", method, MethodOverrideHeader, got)
+		}
+	}
+}
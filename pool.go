@@ -0,0 +1,30 @@
+package httpx
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBodyBuffer caps the buffers kept for reuse, so that a single
+// large response does not pin its memory in the pool.
+const maxPooledBodyBuffer = 1 << 20
+
+// bodyBuffers holds response body buffers for WithResponseBufferReuse.
+var bodyBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBodyBuffer returns an empty buffer from the pool.
+func getBodyBuffer() *bytes.Buffer {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBodyBuffer returns buf to the pool unless it grew too large.
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBodyBuffer {
+		return
+	}
+	bodyBuffers.Put(buf)
+}
@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseBufferReuse(t *testing.T) {
+	type event struct {
+		ID   string `json:"id"`
+		Data []byte `json:"data"`
+	}
+	srv := contentServer(t, "application/json", `{"id":"first","data":"Zmlyc3Q="}`)
+	other := contentServer(t, "application/json", `{"id":"other","data":"b3RoZXI="}`)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithResponseBufferReuse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := JSON[event](res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body is gone once the buffer went back to the pool
+	if _, err := c.(*client).Bytes(res); !errors.Is(err, ErrBodyAlreadyConsumed) {
+		t.Errorf("Bytes after decoding: err = %v", err)
+	}
+
+	// Decoding the next response into a reused buffer leaves the first intact
+	for range 10 {
+		res, err := c.Get(other.URL, WithResponseBufferReuse())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := JSON[event](res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.ID != "first" || string(first.Data) != "first" {
+		t.Errorf("first = %+v, overwritten by a later response", first)
+	}
+}
+
+func BenchmarkResponseBufferReuse(b *testing.B) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	payload := "[" + strings.TrimSuffix(strings.Repeat(`{"id":1,"name":"item"},`, 2000), ",") + "]"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := map[string][]Option{
+		"default": nil,
+		"reuse":   {WithResponseBufferReuse()},
+	}
+	for name, opts := range tests {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(payload)))
+			for range b.N {
+				res, err := c.Get(srv.URL, opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := JSON[[]item](res); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestProfile bundles request settings for a traffic class such as
+// "interactive", "batch" or "critical". Profiles are registered in
+// Config.Profiles and selected per request with WithProfile.
+//
+// Explicit per-request options always override profile values.
+type RequestProfile struct {
+	// Timeout bounds each request like WithTimeout.
+	Timeout time.Duration
+
+	// Retry replaces the client retry policy like WithRetry.
+	Retry *RetryConfig
+
+	// Headers are applied on top of global headers and below per-request
+	// headers.
+	Headers http.Header
+
+	// Priority is sent as the Priority header (RFC 9218), e.g. "u=0" for
+	// critical traffic or "u=6, i" for batch jobs, unless the request or
+	// Headers set one.
+	Priority string
+
+	// LogLevel is the level at which Config.Logger records successful
+	// requests of the profile, e.g. slog.LevelDebug to keep chatty batch
+	// traffic out of the info log. Failures are always logged at error level.
+	// Defaults to slog.LevelInfo.
+	LogLevel slog.Level
+}
+
+// applyProfile fills unset fields of o from the profile selected via
+// WithProfile. An unknown profile name returns an error listing the
+// registered profiles.
+func (c *client) applyProfile(o *RequestOptions) error {
+	if o.Profile == "" {
+		return nil
+	}
+
+	profile, ok := c.Profiles[o.Profile]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for name := range c.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("httpx: unknown request profile %q (registered: %s)",
+			o.Profile, strings.Join(names, ", "))
+	}
+
+	if o.Timeout == 0 {
+		o.Timeout = profile.Timeout
+	}
+
+	if o.Retry == nil {
+		o.Retry = profile.Retry
+	}
+
+	if len(profile.Headers) > 0 || profile.Priority != "" {
+		merged := profile.Headers.Clone()
+		if merged == nil {
+			merged = http.Header{}
+		}
+		if profile.Priority != "" && merged.Get("Priority") == "" {
+			merged.Set("Priority", profile.Priority)
+		}
+		for key, values := range o.Headers {
+			merged[key] = values
+		}
+		o.Headers = merged
+	}
+
+	o.logLevel = profile.LogLevel
+
+	return nil
+}
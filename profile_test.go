@@ -0,0 +1,99 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestProfile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Priority", r.Header.Get("Priority"))
+		w.Header().Set("X-Class", r.Header.Get("X-Class"))
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Profiles: map[string]RequestProfile{
+		"interactive": {
+			Timeout:  50 * time.Millisecond,
+			Headers:  http.Header{"X-Class": {"interactive"}},
+			Priority: "u=0",
+		},
+	}})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithProfile("interactive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Header.Get("X-Priority") != "u=0" || res.Header.Get("X-Class") != "interactive" {
+		t.Errorf("headers = %v, want the profile's", res.Header)
+	}
+
+	// Explicit options beat the profile
+	res, err = c.Get(srv.URL,
+		WithProfile("interactive"),
+		WithHeaders(http.Header{"Priority": {"u=3"}, "X-Class": {"custom"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Header.Get("X-Priority") != "u=3" || res.Header.Get("X-Class") != "custom" {
+		t.Errorf("headers = %v, want the request's", res.Header)
+	}
+
+	if _, err := c.Get(srv.URL+"/slow", WithProfile("interactive")); err == nil {
+		t.Error("profile timeout did not apply")
+	}
+	res, err = c.Get(srv.URL+"/slow", WithProfile("interactive"), WithTimeout(time.Second))
+	if err != nil {
+		t.Errorf("WithTimeout did not override the profile: %v", err)
+	} else {
+		res.Body.Close()
+	}
+}
+
+func TestRequestProfileUnknown(t *testing.T) {
+	c := New(&Config{Profiles: map[string]RequestProfile{"batch": {}, "critical": {}}})
+	defer c.Close(context.Background())
+
+	_, err := c.Get("http://example.invalid", WithProfile("interactve"))
+	if err == nil || !strings.Contains(err.Error(), "registered: batch, critical") {
+		t.Errorf("err = %v, want the registered profiles", err)
+	}
+}
+
+func TestRequestProfileLogLevel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c := New(&Config{
+		Logger:   slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Profiles: map[string]RequestProfile{"batch": {LogLevel: slog.LevelDebug}},
+	})
+	defer c.Close(context.Background())
+
+	for _, opts := range [][]Option{{WithProfile("batch")}, nil} {
+		res, err := c.Get(srv.URL, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "level=DEBUG") || !strings.Contains(lines[1], "level=INFO") {
+		t.Errorf("logs = %q, want a debug line for the profile and an info line otherwise", lines)
+	}
+}
@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+)
+
+// ProgressFunc receives the number of bytes transferred so far and the
+// expected total, which is -1 when unknown, e.g. for chunked transfers
+// without Content-Length. transferred only grows; it is counted, not taken
+// from headers, so a body that outgrows its declared length reports -1 from
+// then on.
+type ProgressFunc func(transferred, total int64)
+
+// progressReader counts the bytes read through it and reports them.
+type progressReader struct {
+	io.ReadCloser
+	total       int64
+	transferred int64
+	progress    ProgressFunc
+}
+
+// Read implements io.Reader.
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+
+		// A declared length that turned out wrong is no longer reported
+		if r.total >= 0 && r.transferred > r.total {
+			r.total = -1
+		}
+		r.progress(r.transferred, r.total)
+	}
+	return n, err
+}
+
+// trackUpload reports upload progress of req to fn. Replayed bodies (retries,
+// redirects) start counting from zero again.
+func trackUpload(req *http.Request, fn ProgressFunc) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+
+	req.Body = &progressReader{ReadCloser: req.Body, total: total, progress: fn}
+
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &progressReader{ReadCloser: body, total: total, progress: fn}, nil
+		}
+	}
+}
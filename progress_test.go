@@ -0,0 +1,102 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressLog records the calls of a ProgressFunc.
+type progressLog struct {
+	mu    sync.Mutex
+	calls [][2]int64
+}
+
+func (l *progressLog) record(transferred, total int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, [2]int64{transferred, total})
+}
+
+func TestUploadProgress(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 256<<10)
+	size := int64(len(payload))
+
+	tests := []struct {
+		name      string
+		body      Option
+		wantTotal int64
+	}{
+		{"encoded body", WithBody(payload), size},
+		{"reader with length", WithBodyReader(onlyReader{bytes.NewReader(payload)}, size), size},
+		{"unknown length", WithBodyReader(onlyReader{bytes.NewReader(payload)}, -1), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := flakyServer(t, 0)
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			var log progressLog
+			res, err := c.Put(srv.URL, tt.body, WithProgress(log.record))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			log.mu.Lock()
+			defer log.mu.Unlock()
+
+			if len(log.calls) == 0 {
+				t.Fatal("progress never reported")
+			}
+			var last int64
+			for i, call := range log.calls {
+				if call[0] <= last {
+					t.Fatalf("call %d: transferred %d after %d", i, call[0], last)
+				}
+				if call[1] != tt.wantTotal {
+					t.Fatalf("call %d: total %d, want %d", i, call[1], tt.wantTotal)
+				}
+				last = call[0]
+			}
+			if last != size {
+				t.Errorf("final transferred = %d, want %d", last, size)
+			}
+		})
+	}
+}
+
+func TestUploadProgressRestartsOnRetry(t *testing.T) {
+	srv := flakyServer(t, 1)
+	payload := bytes.Repeat([]byte("x"), 64<<10)
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	var log progressLog
+	res, err := c.Put(srv.URL, WithBody(payload), WithProgress(log.record))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	// Each attempt counts up to the full body
+	var attempts int
+	var last int64
+	for _, call := range log.calls {
+		if call[0] <= last {
+			attempts++
+		}
+		last = call[0]
+	}
+	if attempts != 1 || last != int64(len(payload)) {
+		t.Errorf("%d restarts, final %d; want 1 restart and %d", attempts, last, len(payload))
+	}
+}
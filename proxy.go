@@ -0,0 +1,48 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// proxyKey is the context key under which a WithProxy override is stored.
+type proxyKey struct{}
+
+// parseProxyURL validates a proxy URL from Config.ProxyURL or WithProxy.
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: invalid proxy URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("httpx: invalid proxy URL %q: scheme and host required", u.Redacted())
+	}
+	return u, nil
+}
+
+// proxyFunc returns the transport Proxy function. A WithProxy override in
+// the request context wins, then the fixed proxy, then the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables. Credentials in the proxy
+// URL are sent as Proxy-Authorization by the transport.
+func proxyFunc(fixed *url.URL) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if u, ok := req.Context().Value(proxyKey{}).(*url.URL); ok {
+			return u, nil
+		}
+		if fixed != nil {
+			return fixed, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+// withProxy routes req through the proxy at raw.
+func withProxy(req *http.Request, raw string) (*http.Request, error) {
+	u, err := parseProxyURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(context.WithValue(req.Context(), proxyKey{}, u)), nil
+}
@@ -0,0 +1,91 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// proxyServer acts as a forward proxy that answers every request itself,
+// echoing the target URL, its name and the proxy credentials.
+func proxyServer(t *testing.T, name string) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-Proxy", name)
+		w.Header().Set("X-Target", r.RequestURI)
+		w.Header().Set("X-Proxy-Authorization", r.Header.Get("Proxy-Authorization"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestWithProxy(t *testing.T) {
+	configured, _ := proxyServer(t, "configured")
+	override, _ := proxyServer(t, "override")
+
+	tests := []struct {
+		name      string
+		config    *Config
+		opts      []Option
+		wantProxy string
+		wantAuth  string
+	}{
+		{"Config.ProxyURL", &Config{ProxyURL: configured.URL}, nil, "configured", ""},
+		{"WithProxy", nil, []Option{WithProxy(override.URL)}, "override", ""},
+		{"WithProxy overrides Config.ProxyURL", &Config{ProxyURL: configured.URL}, []Option{WithProxy(override.URL)}, "override", ""},
+		{
+			"credentials",
+			nil,
+			[]Option{WithProxy("http://ada:s3cret@" + override.Listener.Addr().String())},
+			"override",
+			basicAuthorization("ada", "s3cret"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.config)
+			defer c.Close(context.Background())
+
+			res, err := c.Get("http://origin.test/items?page=2", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Proxy"); got != tt.wantProxy {
+				t.Errorf("routed through %q, want %q", got, tt.wantProxy)
+			}
+			if got := res.Header.Get("X-Target"); got != "http://origin.test/items?page=2" {
+				t.Errorf("proxy saw target %q", got)
+			}
+			if got := res.Header.Get("X-Proxy-Authorization"); got != tt.wantAuth {
+				t.Errorf("Proxy-Authorization = %q, want %q", got, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestWithProxyInvalidURL(t *testing.T) {
+	srv, calls := proxyServer(t, "origin")
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	for _, raw := range []string{"://missing-scheme", "proxy.corp:3128", "http://", "http://proxy\x7f.corp"} {
+		if _, err := c.Get(srv.URL, WithProxy(raw)); err == nil {
+			t.Errorf("WithProxy(%q) succeeded", raw)
+		}
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("%d requests sent despite an invalid proxy", n)
+	}
+
+	if _, err := NewClient(&Config{ProxyURL: "proxy.corp:3128"}); err == nil {
+		t.Error("NewClient accepted an invalid ProxyURL")
+	}
+}
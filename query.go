@@ -0,0 +1,181 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseBaseURL validates Config.BaseURL, which must be absolute.
+func parseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: invalid BaseURL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("httpx: invalid BaseURL %q: scheme and host required", raw)
+	}
+	return u, nil
+}
+
+// ErrInvalidURL is wrapped by the errors returned for request URLs that
+// cannot be sent, e.g. without a scheme or with an unsupported one.
+var ErrInvalidURL = errors.New("httpx: invalid URL")
+
+// validateURL checks that uri is an absolute http or https URL with a host
+// and names the problem otherwise.
+func validateURL(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrInvalidURL, uri, errors.Unwrap(err))
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	case "":
+		return fmt.Errorf("%w %q: missing scheme, e.g. https:// (or set Config.BaseURL for relative paths)", ErrInvalidURL, uri)
+	default:
+		return fmt.Errorf("%w %q: unsupported scheme %q, only http and https are supported", ErrInvalidURL, uri, u.Scheme)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("%w %q: missing host", ErrInvalidURL, uri)
+	}
+	return nil
+}
+
+// resolveURL joins a relative uri onto base. The path of uri is appended to
+// the path of base with exactly one slash in between, unlike RFC 3986
+// resolution which would drop the last segment of the base path. The query
+// strings of both are kept, base first. URLs with a scheme are returned
+// unchanged and scheme-relative ones take the scheme of base. Without a base,
+// uri is returned as is.
+func resolveURL(base *url.URL, uri string) (string, error) {
+	if base == nil {
+		return uri, nil
+	}
+
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if ref.Scheme != "" {
+		return uri, nil
+	}
+	if ref.Host != "" { // scheme-relative "//host/path"
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	u := *base
+	u.Fragment, u.RawFragment = ref.Fragment, ref.RawFragment
+
+	if path := ref.EscapedPath(); path != "" {
+		joined := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(path, "/")
+		if u.Path, err = url.PathUnescape(joined); err != nil {
+			return "", err
+		}
+		u.RawPath = joined
+	}
+
+	switch {
+	case base.RawQuery == "":
+		u.RawQuery = ref.RawQuery
+	case ref.RawQuery != "":
+		u.RawQuery = base.RawQuery + "&" + ref.RawQuery
+	}
+
+	return u.String(), nil
+}
+
+// buildURL appends the query parameters of o to uri.
+//
+// By default, struct-based WithQuery values are merged into the query string
+// already present in uri, and WithParams keys win on collision. When
+// Config.QueryEncoder is set, it receives the raw WithQuery or WithParams
+// value instead and its output becomes the query string verbatim.
+//
+// Config.DefaultParams fill in keys that neither uri nor o sets. A custom
+// QueryEncoder receives them merged into the WithParams map; combined with
+// WithQuery, they are prepended to the encoder output instead.
+func (c *client) buildURL(uri string, o *RequestOptions) (string, error) {
+	if o.Params == nil && o.Query == nil && len(c.DefaultParams) == 0 {
+		return uri, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if c.QueryEncoder != nil {
+		if o.Params != nil && o.Query != nil {
+			return "", fmt.Errorf("httpx: QueryEncoder cannot combine WithQuery and WithParams")
+		}
+
+		var params any = mergeParams(c.DefaultParams, o.Params)
+		if o.Query != nil {
+			params = o.Query
+		}
+
+		raw, err := c.QueryEncoder(params)
+		if err != nil {
+			return "", fmt.Errorf("httpx: encoding query: %w", err)
+		}
+
+		if o.Query != nil && len(c.DefaultParams) > 0 {
+			defaults := make(url.Values)
+			for key, val := range c.DefaultParams {
+				defaults.Set(key, val)
+			}
+			raw = strings.TrimSuffix(defaults.Encode()+"&"+raw, "&")
+		}
+
+		u.RawQuery = raw
+		return u.String(), nil
+	}
+
+	q := u.Query()
+
+	// Defaults only fill keys the URL does not already carry
+	for key, val := range c.DefaultParams {
+		if !q.Has(key) {
+			q.Set(key, val)
+		}
+	}
+
+	// Struct-based query values first, explicit params win on collision
+	if o.Query != nil {
+		values, err := encodeValues(o.Query, paramFormat{boolAsInt: o.QueryBoolAsInt})
+		if err != nil {
+			return "", err
+		}
+		for key, vals := range values {
+			q[key] = vals
+		}
+	}
+
+	for key, val := range o.Params {
+		q.Set(key, val)
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// mergeParams returns params with defaults filled in for missing keys. The
+// input maps are not modified.
+func mergeParams(defaults, params map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return params
+	}
+
+	merged := make(map[string]string, len(defaults)+len(params))
+	for key, val := range defaults {
+		merged[key] = val
+	}
+	for key, val := range params {
+		merged[key] = val
+	}
+	return merged
+}
@@ -0,0 +1,251 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// queryServer echoes the raw query string it received.
+func queryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Query", r.URL.RawQuery)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithQueryBoolAsInt(t *testing.T) {
+	srv := queryServer(t)
+
+	type filter struct {
+		Active   bool   `url:"active"`
+		Archived bool   `url:"archived"`
+		Deleted  *bool  `url:"deleted,omitempty"`
+		Flags    []bool `url:"flag"`
+	}
+	in := filter{Active: true, Flags: []bool{true, false}}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", []Option{WithQuery(in)}, "active=true&archived=false&flag=true&flag=false"},
+		{"as int", []Option{WithQuery(in), WithQueryBoolAsInt()}, "active=1&archived=0&flag=1&flag=0"},
+		{"params untouched", []Option{WithParams(map[string]string{"active": "true"}), WithQueryBoolAsInt()}, "active=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Query"); got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base string
+		uri  string
+		want string
+	}{
+		{"https://api.com", "/users", "https://api.com/users"},
+		{"https://api.com", "users", "https://api.com/users"},
+		{"https://api.com/", "/users", "https://api.com/users"},
+		{"https://api.com/v1", "/users", "https://api.com/v1/users"},
+		{"https://api.com/v1/", "users", "https://api.com/v1/users"},
+		{"https://api.com/v1/", "/users/", "https://api.com/v1/users/"},
+		{"https://api.com/v1", "", "https://api.com/v1"},
+		{"https://api.com/v1", "users/a%2Fb", "https://api.com/v1/users/a%2Fb"},
+		{"https://api.com/v1?key=k", "/users?page=2", "https://api.com/v1/users?key=k&page=2"},
+		{"https://api.com/v1?key=k", "/users", "https://api.com/v1/users?key=k"},
+		{"https://api.com/v1", "?page=2", "https://api.com/v1?page=2"},
+		{"https://api.com/v1", "/users#top", "https://api.com/v1/users#top"},
+		{"https://api.com/v1", "http://other.com/x?y=1", "http://other.com/x?y=1"},
+		{"https://api.com/v1", "//cdn.com/img", "https://cdn.com/img"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base+" + "+tt.uri, func(t *testing.T) {
+			base, err := parseBaseURL(tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveURL(base, tt.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without a base the URL is used as is
+	if got, _ := resolveURL(nil, "/users"); got != "/users" {
+		t.Errorf("without base: %q", got)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-URI", r.RequestURI)
+	}))
+	defer srv.Close()
+
+	c := New(&Config{BaseURL: srv.URL + "/api/?key=k"})
+	defer c.Close(context.Background())
+
+	res, err := c.Get("/users", WithParams(map[string]string{"page": "2"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Request-URI"); got != "/api/users?key=k&page=2" {
+		t.Errorf("server got %q", got)
+	}
+
+	// Invalid base URLs are rejected up front
+	if _, err := NewClient(&Config{BaseURL: "api.com/v1"}); err == nil {
+		t.Error("NewClient accepted a base URL without scheme")
+	}
+}
+
+func TestInvalidURL(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"api.com/users", "missing scheme"},
+		{"/users", "missing scheme"},
+		{"ftp://files.com/a.txt", `unsupported scheme "ftp"`},
+		{"file:///etc/passwd", `unsupported scheme "file"`},
+		{"http:///users", "missing host"},
+		{"http://[::1", "invalid URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			_, err := c.Get(tt.uri)
+			if !errors.Is(err, ErrInvalidURL) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want ErrInvalidURL naming %q", err, tt.want)
+			}
+		})
+	}
+
+	// Valid schemes are accepted in any case
+	res, err := c.Get(strings.Replace(srv.URL, "http://", "HTTP://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if hits.Load() != 1 {
+		t.Errorf("server hit %d times, want only the valid request", hits.Load())
+	}
+}
+
+func TestDefaultParams(t *testing.T) {
+	srv := queryServer(t)
+
+	c := New(&Config{DefaultParams: map[string]string{"api_key": "k", "lang": "en"}})
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name string
+		path string
+		opts []Option
+		want string
+	}{
+		{"defaults only", "/", nil, "api_key=k&lang=en"},
+		{"merged with params", "/", []Option{WithParams(map[string]string{"page": "2"})}, "api_key=k&lang=en&page=2"},
+		{"params win", "/", []Option{WithParams(map[string]string{"lang": "de"})}, "api_key=k&lang=de"},
+		{"URL wins", "/?lang=fr", nil, "api_key=k&lang=fr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(srv.URL+tt.path, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Query"); got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryEncoder(t *testing.T) {
+	srv := queryServer(t)
+
+	type filter struct{ Tags []string }
+	failed := errors.New("unsupported")
+
+	c := New(&Config{QueryEncoder: func(params any) (string, error) {
+		switch p := params.(type) {
+		case map[string]string:
+			return "p=" + p["id"], nil
+		case filter:
+			return "tags=" + strings.Join(p.Tags, "|"), nil
+		}
+		return "", failed
+	}})
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name string
+		path string
+		opts []Option
+		want string
+	}{
+		{"params map", "/", []Option{WithParams(map[string]string{"id": "7"})}, "p=7"},
+		{"query struct", "/", []Option{WithQuery(filter{Tags: []string{"a", "b"}})}, "tags=a|b"},
+		{"replaces the URL query", "/?old=1", []Option{WithParams(map[string]string{"id": "7"})}, "p=7"},
+		{"unused without params", "/?old=1", nil, "old=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(srv.URL+tt.path, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got := res.Header.Get("X-Query"); got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := c.Get(srv.URL, WithQuery(42)); !errors.Is(err, failed) {
+		t.Errorf("err = %v, want the encoder error", err)
+	}
+	if _, err := c.Get(srv.URL, WithQuery(filter{}), WithParams(map[string]string{"id": "7"})); err == nil {
+		t.Error("WithQuery and WithParams combined with a QueryEncoder")
+	}
+}
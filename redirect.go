@@ -0,0 +1,73 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// defaultMaxRedirects is the number of redirects followed by default. Unlike
+// net/http, which fails on the 10th redirect and so follows only nine, a
+// limit of n follows n redirects and fails on the next one.
+const defaultMaxRedirects = 10
+
+// redirectLimitKey is the context key under which a WithMaxRedirects
+// override is stored.
+type redirectLimitKey struct{}
+
+// checkRedirect implements the redirect policy of Config.FollowRedirects,
+// Config.MaxRedirects and WithMaxRedirects for all engines of the client.
+// Redirected requests share the context of the original request, so the
+// per-request limit is found there.
+func (c *client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.FollowRedirects != nil && !*c.FollowRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	limit := c.MaxRedirects
+	if override, ok := req.Context().Value(redirectLimitKey{}).(int); ok {
+		limit = override
+	}
+	if limit <= 0 {
+		limit = defaultMaxRedirects
+	}
+
+	// via holds the requests already sent, so req is redirect number len(via)
+	if len(via) > limit {
+		return fmt.Errorf("httpx: stopped after %d redirects", limit)
+	}
+	return nil
+}
+
+// withRedirectLimit applies the WithMaxRedirects limit n to req.
+func withRedirectLimit(req *http.Request, n int) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), redirectLimitKey{}, n))
+}
+
+// RedirectHistory returns the URLs a request visited, from the original URL
+// to the one that produced res. Without redirects it holds just the request
+// URL.
+//
+// Example:
+//
+//	res, _ := client.Get("http://example.com/old")
+//	fmt.Println(httpx.RedirectHistory(res)) // [http://example.com/old https://example.com/new]
+func RedirectHistory(res *http.Response) []string {
+	var history []string
+
+	for req := res.Request; req != nil; {
+		history = append(history, req.URL.String())
+
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+
+	// Collected backwards from the final request
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+
+	return history
+}
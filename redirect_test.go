@@ -0,0 +1,131 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// redirectChain redirects /n to /n-1 until /0, which answers 200.
+func redirectChain(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if n > 0 {
+			http.Redirect(w, r, "/"+strconv.Itoa(n-1), http.StatusFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMaxRedirects(t *testing.T) {
+	srv := redirectChain(t)
+
+	tests := []struct {
+		name      string
+		config    int
+		request   int
+		redirects int
+		wantErr   bool
+	}{
+		{"default limit", 0, 0, 10, false},
+		{"past default limit", 0, 0, 11, true},
+		{"config limit", 3, 0, 3, false},
+		{"past config limit", 3, 0, 4, true},
+		{"request raises limit", 3, 20, 15, false},
+		{"request lowers limit", 0, 1, 2, true},
+		{"request lowers config limit", 5, 2, 2, false},
+		{"past lowered config limit", 5, 2, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(&Config{MaxRedirects: tt.config})
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL+"/"+strconv.Itoa(tt.redirects), WithMaxRedirects(tt.request))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "stopped after") {
+					t.Errorf("err = %v, want the redirect limit", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if history := RedirectHistory(res); len(history) != tt.redirects+1 {
+				t.Errorf("history has %d URLs, want %d", len(history), tt.redirects+1)
+			}
+		})
+	}
+}
+
+func TestMaxRedirectsPerRequestOnly(t *testing.T) {
+	srv := redirectChain(t)
+
+	c := New(&Config{MaxRedirects: 5})
+	defer c.Close(context.Background())
+
+	// Overrides on one request leave the client's limit alone
+	for _, n := range []int{1, 20} {
+		res, err := c.Get(srv.URL+"/4", WithMaxRedirects(n))
+		if err == nil {
+			res.Body.Close()
+		}
+		if failed := err != nil; failed != (n < 4) {
+			t.Errorf("limit %d: err = %v", n, err)
+		}
+
+		res, err = c.Get(srv.URL + "/5")
+		if err != nil {
+			t.Fatalf("after limit %d: %v", n, err)
+		}
+		res.Body.Close()
+		if _, err := c.Get(srv.URL + "/6"); err == nil {
+			t.Errorf("after limit %d: client limit raised", n)
+		}
+	}
+}
+
+func TestFollowRedirectsFalse(t *testing.T) {
+	srv := redirectChain(t)
+
+	follow := false
+	c := New(&Config{FollowRedirects: &follow})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusFound || res.Header.Get("Location") != "/1" {
+		t.Errorf("got %d to %q, want the first redirect", res.StatusCode, res.Header.Get("Location"))
+	}
+}
+
+func TestRedirectHistory(t *testing.T) {
+	srv := redirectChain(t)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	want := []string{srv.URL + "/2", srv.URL + "/1", srv.URL + "/0"}
+	if got := RedirectHistory(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedirectHistory = %v, want %v", got, want)
+	}
+}
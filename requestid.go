@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader is the header used by WithRequestIDEcho when no
+// header name is given.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// RequestIDMismatchError is returned for requests sent with
+// WithRequestIDEcho when the response does not echo the request ID, which
+// points to a proxy or cache answering with someone else's response.
+type RequestIDMismatchError struct {
+	Header   string // header carrying the ID
+	Sent     string // ID sent with the request
+	Received string // ID in the response, empty if missing
+	Response *http.Response
+}
+
+// Error implements the error interface.
+func (e *RequestIDMismatchError) Error() string {
+	if e.Received == "" {
+		return fmt.Sprintf("httpx: response does not echo %s %q", e.Header, e.Sent)
+	}
+	return fmt.Sprintf("httpx: response echoes %s %q, sent %q", e.Header, e.Received, e.Sent)
+}
+
+// newRequestID returns a random 128-bit ID in hex.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// checkRequestIDEcho verifies that res echoes the ID sent in header. On a
+// mismatch the body is closed.
+func checkRequestIDEcho(req *http.Request, res *http.Response, header string) error {
+	sent := req.Header.Get(header)
+	received := res.Header.Get(header)
+	if received == sent {
+		return nil
+	}
+
+	res.Body.Close()
+	return &RequestIDMismatchError{Header: header, Sent: sent, Received: received, Response: res}
+}
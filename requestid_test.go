@@ -0,0 +1,78 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRequestIDEcho(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only one of the headers is set per request
+		sent = r.Header.Get("X-Request-Id") + r.Header.Get("X-Trace")
+		switch r.URL.Path {
+		case "/echo":
+			w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+			w.Header().Set("X-Trace", r.Header.Get("X-Trace"))
+		case "/cached":
+			w.Header().Set("X-Request-Id", "someone-else")
+		}
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name     string
+		path     string
+		opts     []Option
+		header   string
+		received string // echoed ID on mismatch, "-" for a match
+	}{
+		{"match", "/echo", []Option{WithRequestIDEcho("")}, "X-Request-Id", "-"},
+		{"custom header", "/echo", []Option{WithRequestIDEcho("X-Trace")}, "X-Trace", "-"},
+		{"mismatch", "/cached", []Option{WithRequestIDEcho("")}, "X-Request-Id", "someone-else"},
+		{"missing", "/", []Option{WithRequestIDEcho("")}, "X-Request-Id", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Get(srv.URL+tt.path, tt.opts...)
+			if len(sent) != 32 {
+				t.Errorf("sent ID %q, want a generated one", sent)
+			}
+
+			if tt.received == "-" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+				if got := res.Header.Get(tt.header); got != sent {
+					t.Errorf("echo = %q, sent %q", got, sent)
+				}
+				return
+			}
+
+			var mismatch *RequestIDMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("err = %v, want a *RequestIDMismatchError", err)
+			}
+			if mismatch.Header != tt.header || mismatch.Sent != sent || mismatch.Received != tt.received || mismatch.Response == nil {
+				t.Errorf("mismatch = %+v", mismatch)
+			}
+		})
+	}
+
+	// A caller-provided ID is sent as is
+	res, err := c.Get(srv.URL+"/echo", WithRequestIDEcho(""), WithHeaders(http.Header{"X-Request-Id": {"mine"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if sent != "mine" {
+		t.Errorf("sent ID %q, want the caller's", sent)
+	}
+}
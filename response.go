@@ -1,11 +1,18 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
 // HttpError represents an HTTP error returned by the server when the response
@@ -13,12 +20,21 @@ import (
 // such as the status code, response body, headers, and the originating request.
 // This mirrors the behavior of Axios' error.response object.
 type HttpError struct {
-	StatusCode int         // HTTP status code (e.g., 404, 500)
-	Status     string      // Full status string (e.g., "404 Not Found")
-	Body       []byte      // Raw response body for debugging or custom decoding
-	Headers    http.Header // Response headers returned by the server
-	Method     string      // HTTP method of the originating request
-	URL        string      // Request URL that caused the error
+	StatusCode int           // HTTP status code (e.g., 404, 500)
+	Status     string        // Full status string (e.g., "404 Not Found")
+	Body       []byte        // Raw response body for debugging or custom decoding
+	Headers    http.Header   // Response headers returned by the server
+	Method     string        // HTTP method of the originating request
+	SentAs     string        // Method sent on the wire when a method override was used
+	Timings    []PhaseTiming // Request phases up to reading the body, if Config.CollectTimings is set
+	URL        string        // Request URL that caused the error
+	RetryAfter time.Duration // Delay advised via Retry-After on 429/503, zero if absent
+	Proto      string        // Protocol of the response, e.g. "HTTP/1.1"
+
+	// Response is the original response with its body already read, e.g. to
+	// inspect trailers. Method and URL are empty when its Request is nil, as
+	// with hand-built responses.
+	Response *http.Response
 }
 
 // Error implements the error interface. A short body snippet is included
@@ -28,41 +44,555 @@ func (e *HttpError) Error() string {
 	if len(snippet) > 200 {
 		snippet = snippet[:200] + "..."
 	}
-	return fmt.Sprintf("httpx: %s %s returned %d (%s)", e.Method, e.URL, e.StatusCode, snippet)
+	method := e.Method
+	if e.SentAs != "" {
+		method += " (sent as " + e.SentAs + ")"
+	}
+	target := strings.TrimSpace(method + " " + e.URL)
+	if target == "" {
+		target = "response" // hand-built response without a request
+	}
+	return fmt.Sprintf("httpx: %s returned %d (%s)", target, e.StatusCode, snippet)
 }
 
-// readBodyWithStatus reads and returns the full response body. If the response
-// status code is not within the 2xx success range, an HttpError is returned
-// containing the response metadata.
-// This function is used internally by all response helpers.
-func readBodyWithStatus(res *http.Response) ([]byte, error) {
+// IsPreconditionFailed reports whether the server rejected a conditional
+// request (e.g. WithIfMatch) with 412 Precondition Failed.
+func (e *HttpError) IsPreconditionFailed() bool {
+	return e.StatusCode == http.StatusPreconditionFailed
+}
+
+// IsBadRequest reports a 400 Bad Request.
+func (e *HttpError) IsBadRequest() bool {
+	return e.StatusCode == http.StatusBadRequest
+}
+
+// IsUnauthorized reports a 401 Unauthorized.
+func (e *HttpError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// IsForbidden reports a 403 Forbidden.
+func (e *HttpError) IsForbidden() bool {
+	return e.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound reports a 404 Not Found.
+func (e *HttpError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsConflict reports a 409 Conflict.
+func (e *HttpError) IsConflict() bool {
+	return e.StatusCode == http.StatusConflict
+}
+
+// IsTooManyRequests reports a 429 Too Many Requests; see RetryAfter for the
+// advised delay.
+func (e *HttpError) IsTooManyRequests() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsClientError reports a 4xx status.
+func (e *HttpError) IsClientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode <= 499
+}
+
+// IsServerError reports a 5xx status.
+func (e *HttpError) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode <= 599
+}
+
+// StatusCode returns the status code of the first HttpError in err's chain,
+// so wrapped errors work as well. It reports false when err contains no
+// HttpError.
+//
+// Example:
+//
+//	_, err := httpx.JSON[User](res)
+//	if code, ok := httpx.StatusCode(err); ok && code == http.StatusNotFound {
+//	    return nil
+//	}
+func StatusCode(err error) (int, bool) {
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) {
+		return 0, false
+	}
+	return httpErr.StatusCode, true
+}
+
+// JSON decodes the error response body into target. This is useful for APIs
+// that describe failures with a structured payload such as
+// {"code": "...", "message": "..."}.
+//
+// Empty or malformed bodies return a decoding error that wraps both the cause
+// (ErrEmptyBody for empty bodies) and the HttpError itself, so the status
+// code stays reachable via errors.As.
+//
+// Example:
+//
+//	var apiErr APIError
+//	if httpErr, ok := err.(*httpx.HttpError); ok {
+//	    _ = httpErr.JSON(&apiErr)
+//	}
+func (e *HttpError) JSON(target any) error {
+	if len(bytes.TrimSpace(e.Body)) == 0 {
+		return &errorBodyError{httpErr: e, err: ErrEmptyBody}
+	}
+
+	unmarshal := json.Unmarshal
+	if e.Response != nil {
+		unmarshal = readOptionsFor(e.Response).unmarshalJSON
+	}
+	if err := unmarshal(e.Body, target); err != nil {
+		return &errorBodyError{httpErr: e, err: err}
+	}
+
+	return nil
+}
+
+// errorBodyError reports that the body of an HttpError could not be decoded.
+type errorBodyError struct {
+	httpErr *HttpError
+	err     error
+}
+
+func (e *errorBodyError) Error() string {
+	return fmt.Sprintf("httpx: failed to decode JSON error body of %d response: %v", e.httpErr.StatusCode, e.err)
+}
+
+func (e *errorBodyError) Unwrap() []error {
+	return []error{e.err, e.httpErr}
+}
+
+// ErrorAs finds the first HttpError in err's chain and decodes its JSON body
+// into a value of type T. The boolean result is false when err contains no
+// HttpError or its body cannot be decoded as T.
+//
+// Example:
+//
+//	_, err := httpx.JSON[User](res)
+//	if apiErr, ok := httpx.ErrorAs[APIError](err); ok {
+//	    fmt.Println(apiErr.Code, apiErr.Message)
+//	}
+func ErrorAs[T any](err error) (T, bool) {
+	var out T
+
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) {
+		return out, false
+	}
+
+	if err := httpErr.JSON(&out); err != nil {
+		return out, false
+	}
+
+	return out, true
+}
+
+// ErrBodyAlreadyConsumed is returned by the response helpers when the body
+// was already streamed by Download, or its buffer was returned to the pool
+// after decoding with WithResponseBufferReuse, and can therefore not be read
+// again.
+var ErrBodyAlreadyConsumed = errors.New("httpx: response body already consumed")
+
+// ErrEmptyBody is returned by the decoding helpers when a successful response
+// has no body although WithRequireBody was set on the request.
+var ErrEmptyBody = errors.New("httpx: response body is empty")
+
+// ErrResponseTooLarge matches the *BodyTooLargeError returned by the
+// response helpers when a body exceeds Config.MaxResponseBytes or
+// WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("httpx: response body too large")
+
+// BodyTooLargeError is returned by the response helpers when a response body
+// exceeds its size limit. The limit applies to the decoded body, after any
+// Content-Encoding is undone; reading stops one byte past it.
+type BodyTooLargeError struct {
+	Limit int64 // configured limit in bytes
+	Read  int64 // bytes read before giving up
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("httpx: response body too large: read %d bytes, limit %d", e.Read, e.Limit)
+}
+
+// Is lets errors.Is match ErrResponseTooLarge.
+func (e *BodyTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// readOptions carries per-request settings that influence how the response
+// helpers read a body. It travels with the request context so that helpers
+// only need the *http.Response.
+type readOptions struct {
+	requireBody bool   // empty 2xx bodies fail decoding with ErrEmptyBody
+	method      string // logical method when sent via a method override
+	timings     bool   // expose phase timings via HttpError and Timings
+	rawStatus   bool   // non-2xx responses are read without an HttpError
+	reuse       bool   // read into a pooled buffer, released after decoding
+	maxBytes    int64  // body size limit, 0 for unlimited
+	maxLine     int    // line size limit of JSONLines and ReadEvents, 0 for the default
+
+	logLevel slog.Level // level of successful requests in Config.Logger
+
+	isSuccess func(statusCode int) bool // Config.IsSuccess, nil for 2xx
+	tasks     *taskTracker              // goroutines of the sending client, e.g. of ReadEvents
+
+	unmarshal  func(data []byte, v any) error // Config.JSONUnmarshal, nil for encoding/json
+	strictJSON bool                           // reject unknown fields with encoding/json
+
+	// The body is read once and replayed to later helper calls
+	mu       sync.Mutex
+	read     bool   // body has been read by a helper
+	streamed bool   // body was consumed by Download without buffering
+	buffered []byte // decompressed body after the first read
+	readErr  error  // error of the first read, returned again on replay
+
+	pooled   *bytes.Buffer // buffer behind the body when reuse is set
+	released bool          // pooled buffer was returned after decoding
+}
+
+// body returns the decompressed body of res, reading it on the first call
+// and replaying the result afterwards. Bodies consumed by Download cannot be
+// replayed and return ErrBodyAlreadyConsumed.
+func (ro *readOptions) body(res *http.Response) ([]byte, error) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	if ro.streamed || ro.released {
+		return nil, ErrBodyAlreadyConsumed
+	}
+	if ro.read {
+		return ro.buffered, ro.readErr
+	}
+
+	ro.read = true
+
+	// Hand-built responses may have no body at all
+	if res.Body == nil {
+		return nil, nil
+	}
 	defer res.Body.Close()
 
-	// Read raw body
-	body, err := io.ReadAll(res.Body)
+	// Undo Content-Encoding the transport did not handle itself
+	decoded, err := decompressStream(res)
 	if err != nil {
+		ro.readErr = err
+		return nil, err
+	}
+	defer decoded.Close()
+
+	// The limit applies to the decoded bytes, so small compressed bodies
+	// cannot expand without bound; read one byte past it to detect an excess
+	var src io.Reader = decoded
+	if ro.maxBytes > 0 {
+		src = io.LimitReader(decoded, ro.maxBytes+1)
+	}
+
+	var body []byte
+	if ro.reuse {
+		ro.pooled = getBodyBuffer()
+		_, err = ro.pooled.ReadFrom(src)
+		body = ro.pooled.Bytes()
+	} else {
+		body, err = io.ReadAll(src)
+	}
+	if err == nil && ro.maxBytes > 0 && int64(len(body)) > ro.maxBytes {
+		// Keep the truncated body for the HttpError of failed responses
+		err = &BodyTooLargeError{Limit: ro.maxBytes, Read: int64(len(body))}
+		body = body[:ro.maxBytes]
+	}
+
+	ro.buffered, ro.readErr = body, err
+	return body, err
+}
+
+// stream marks the body as consumed without buffering, as done by Download.
+// It returns the buffered body if a helper already read it.
+func (ro *readOptions) stream() (buffered []byte, ok bool, err error) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	if ro.streamed || ro.released {
+		return nil, false, ErrBodyAlreadyConsumed
+	}
+	if ro.read {
+		return ro.buffered, true, ro.readErr
+	}
+
+	ro.streamed = true
+	return nil, false, nil
+}
+
+// streamBody marks the body of res as streamed and returns it decompressed,
+// or replays it from memory if a helper already buffered it. Closing the
+// result does not close res.Body. On error res.Body is closed.
+func streamBody(res *http.Response) (io.ReadCloser, error) {
+	buffered, ok, err := readOptionsFor(res).stream()
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if ok {
+		return io.NopCloser(bytes.NewReader(buffered)), nil
+	}
+
+	body, err := decompressStream(res)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return body, nil
+}
+
+// release returns the pooled buffer of a body read with reuse set. Later
+// helper calls return ErrBodyAlreadyConsumed.
+func (ro *readOptions) release() {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+
+	if ro.pooled == nil {
+		return
+	}
+
+	putBodyBuffer(ro.pooled)
+	ro.pooled, ro.buffered = nil, nil
+	ro.released = true
+}
+
+// readOptionsKey is the context key under which readOptions are stored.
+type readOptionsKey struct{}
+
+// withReadOptions attaches the response-related settings of o to req.
+// method is the logical method of the request.
+func (c *client) withReadOptions(req *http.Request, o *RequestOptions, method string) *http.Request {
+	ro := &readOptions{
+		requireBody: o.RequireBody,
+		timings:     c.CollectTimings,
+		rawStatus:   c.RawStatus || o.RawStatus,
+		reuse:       o.ResponseBufferReuse,
+		isSuccess:   c.IsSuccess,
+		unmarshal:   c.JSONUnmarshal,
+		strictJSON:  c.StrictJSON || o.StrictJSON,
+		maxBytes:    c.MaxResponseBytes,
+		tasks:       c.tasks,
+		logLevel:    o.logLevel,
+	}
+	if o.MaxResponseBytes > 0 {
+		ro.maxBytes = o.MaxResponseBytes
+	}
+	if o.MaxLineBytes > 0 {
+		ro.maxLine = o.MaxLineBytes
+	}
+	if method != req.Method {
+		ro.method = method
+	}
+	return req.WithContext(context.WithValue(req.Context(), readOptionsKey{}, ro))
+}
+
+// readOptionsFor returns the read settings of the request behind res, or
+// zero settings when the response was not produced by httpx.
+func readOptionsFor(res *http.Response) *readOptions {
+	if res.Request != nil {
+		if ro, ok := res.Request.Context().Value(readOptionsKey{}).(*readOptions); ok {
+			return ro
+		}
+	}
+	return &readOptions{}
+}
+
+// unmarshalJSON decodes data with Config.JSONUnmarshal or encoding/json,
+// rejecting unknown object fields in strict mode.
+func (ro *readOptions) unmarshalJSON(data []byte, v any) error {
+	if ro.unmarshal != nil {
+		return ro.unmarshal(data, v)
+	}
+	if ro.strictJSON {
+		return unmarshalStrict(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unmarshalStrict is json.Unmarshal with DisallowUnknownFields.
+func unmarshalStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	// Like json.Unmarshal, reject data after the value
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level JSON value")
+	}
+	return nil
+}
+
+// jsonCodec returns the codec for JSON bodies of the request: JSONCodec
+// unless Config.JSONUnmarshal is set.
+func (ro *readOptions) jsonCodec() Codec {
+	return Codec{Name: JSONCodec.Name, Unmarshal: ro.unmarshalJSON}
+}
+
+// failed reports whether a response with the given status code is turned
+// into an HttpError by the helpers.
+func (ro *readOptions) failed(statusCode int) bool {
+	if ro.rawStatus {
+		return false
+	}
+	if ro.isSuccess != nil {
+		return !ro.isSuccess(statusCode)
+	}
+	return statusCode < 200 || statusCode > 299
+}
+
+// readBodyForDecode reads the body like readBodyWithStatus and additionally
+// enforces WithRequireBody for helpers that decode the payload.
+func readBodyForDecode(res *http.Response) ([]byte, error) {
+	b, err := readBodyWithStatus(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 && readOptionsFor(res).requireBody {
+		return nil, ErrEmptyBody
+	}
+
+	return b, nil
+}
+
+// isEmptyHead reports whether b is the (always empty) body of a response to
+// a HEAD request, which decoding helpers treat as a zero value.
+func isEmptyHead(res *http.Response, b []byte) bool {
+	return len(b) == 0 && res.Request != nil && res.Request.Method == http.MethodHead
+}
+
+// readBodyWithStatus reads and returns the full, decompressed response body.
+// If the response status code is not within the 2xx success range (or
+// rejected by Config.IsSuccess), an HttpError is returned containing the
+// response metadata, unless the request was sent with WithRawStatus. Bodies
+// over the size limit return a *BodyTooLargeError, or are truncated to the
+// limit in the HttpError.
+// This function is used internally by all response helpers.
+//
+// Bodies of responses returned by httpx are read once and replayed on
+// subsequent calls, so helpers may be combined on the same response.
+func readBodyWithStatus(res *http.Response) ([]byte, error) {
+	ro := readOptionsFor(res)
+	body, err := ro.body(res)
+
+	// Oversized error bodies are truncated rather than hiding the status
+	var tooLarge *BodyTooLargeError
+	if err != nil && !(errors.As(err, &tooLarge) && ro.failed(res.StatusCode)) {
 		return nil, err
 	}
 
 	// Non-2xx responses return an HttpError
-	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return nil, &HttpError{
+	if ro.failed(res.StatusCode) {
+		delay, _ := retryAfter(res)
+
+		httpErr := &HttpError{
 			StatusCode: res.StatusCode,
 			Status:     res.Status,
 			Body:       body,
 			Headers:    res.Header.Clone(),
-			Method:     res.Request.Method,
-			URL:        res.Request.URL.String(),
+			Method:     LogicalMethod(res.Request),
+			SentAs:     sentAs(res.Request),
+			Timings:    Timings(res),
+			RetryAfter: delay,
+			Proto:      res.Proto,
+			Response:   res,
 		}
+
+		// Responses built by recorders or caches may lack a request
+		if res.Request != nil && res.Request.URL != nil {
+			httpErr.URL = res.Request.URL.String()
+		}
+
+		return nil, httpErr
 	}
 
 	return body, nil
 }
 
+// AllowedMethods parses the Allow header of a response into a normalized
+// slice of upper-case method names in the order the server listed them.
+// Duplicates and empty entries are dropped; multiple Allow header lines are
+// combined. The response body is not touched.
+//
+// Example:
+//
+//	res, _ := client.Options("https://api.com/users")
+//	methods := httpx.AllowedMethods(res) // [GET POST OPTIONS]
+func AllowedMethods(res *http.Response) []string {
+	var methods []string
+	seen := make(map[string]bool)
+
+	for _, line := range res.Header.Values("Allow") {
+		for _, m := range strings.Split(line, ",") {
+			m = strings.ToUpper(strings.TrimSpace(m))
+			if m == "" || seen[m] {
+				continue
+			}
+			seen[m] = true
+			methods = append(methods, m)
+		}
+	}
+
+	return methods
+}
+
+// CookieMap flattens the cookies set by a response (Set-Cookie headers) into
+// a name → value map. When the same name is set more than once, the last
+// Set-Cookie header wins. Attributes such as Path or Expires are dropped; use
+// res.Cookies() when they matter. The response body is not touched.
+//
+// Example:
+//
+//	cookies := httpx.CookieMap(res)
+//	session := cookies["session_id"]
+func CookieMap(res *http.Response) map[string]string {
+	cookies := make(map[string]string)
+	for _, cookie := range res.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	return cookies
+}
+
+// Timings returns the phases of the request behind res with their
+// durations, e.g. DNS, dialing, TLS handshaking and waiting for headers. The
+// last phase is still in progress and measured up to the call. It returns nil
+// unless Config.CollectTimings is set.
+//
+// Example:
+//
+//	res, _ := client.Get("https://api.com/users")
+//	for _, t := range httpx.Timings(res) {
+//	    log.Printf("%s: %s", t.Phase, t.Duration)
+//	}
+func Timings(res *http.Response) []PhaseTiming {
+	if res.Request == nil || !readOptionsFor(res).timings {
+		return nil
+	}
+
+	p := phaseTrackerFrom(res.Request.Context())
+	if p == nil {
+		return nil
+	}
+	return p.timings()
+}
+
 // Bytes reads and returns the response body as raw bytes. If the response
 // contains a non-2xx status code, an HttpError is returned instead.
 func (c *client) Bytes(res *http.Response) ([]byte, error) {
-	return readBodyWithStatus(res)
+	b, err := readBodyWithStatus(res)
+	if err != nil {
+		return nil, err
+	}
+
+	// The buffered body is shared with later helper calls
+	return bytes.Clone(b), nil
 }
 
 // Text reads and returns the response body as a UTF-8 string.
@@ -84,16 +614,17 @@ func (c *client) Text(res *http.Response) (string, error) {
 //	var user User
 //	err := client.ReadJSON(res, &user)
 func (c *client) ReadJSON(res *http.Response, target any) error {
-	b, err := readBodyWithStatus(res)
+	b, err := readBodyForDecode(res)
 	if err != nil {
 		return err
 	}
+	defer readOptionsFor(res).release()
 
 	if len(b) == 0 {
 		return nil
 	}
 
-	if err := json.Unmarshal(b, target); err != nil {
+	if err := readOptionsFor(res).unmarshalJSON(b, target); err != nil {
 		return fmt.Errorf("httpx: failed to decode JSON: %w", err)
 	}
 
@@ -109,16 +640,17 @@ func (c *client) ReadJSON(res *http.Response, target any) error {
 func JSON[T any](res *http.Response) (T, error) {
 	var out T
 
-	b, err := readBodyWithStatus(res)
+	b, err := readBodyForDecode(res)
 	if err != nil {
 		return out, err
 	}
+	defer readOptionsFor(res).release()
 
 	if len(b) == 0 {
 		return out, nil
 	}
 
-	if err := json.Unmarshal(b, &out); err != nil {
+	if err := readOptionsFor(res).unmarshalJSON(b, &out); err != nil {
 		return out, fmt.Errorf("httpx: failed to decode JSON: %w", err)
 	}
 
@@ -133,10 +665,15 @@ func JSON[T any](res *http.Response) (T, error) {
 //	var feed AtomFeed
 //	err := client.ReadXML(res, &feed)
 func (c *client) ReadXML(res *http.Response, target any) error {
-	b, err := readBodyWithStatus(res)
+	b, err := readBodyForDecode(res)
 	if err != nil {
 		return err
 	}
+	defer readOptionsFor(res).release()
+
+	if isEmptyHead(res, b) {
+		return nil
+	}
 
 	if err := xml.Unmarshal(b, target); err != nil {
 		return fmt.Errorf("httpx: failed to decode XML: %w", err)
@@ -154,10 +691,15 @@ func (c *client) ReadXML(res *http.Response, target any) error {
 func XML[T any](res *http.Response) (T, error) {
 	var out T
 
-	b, err := readBodyWithStatus(res)
+	b, err := readBodyForDecode(res)
 	if err != nil {
 		return out, err
 	}
+	defer readOptionsFor(res).release()
+
+	if isEmptyHead(res, b) {
+		return out, nil
+	}
 
 	if err := xml.Unmarshal(b, &out); err != nil {
 		return out, fmt.Errorf("httpx: failed to decode XML: %w", err)
@@ -0,0 +1,638 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseBytes(t *testing.T) {
+	// Both payloads have the same size
+	name := strings.Repeat("x", 100)
+	payload := `{"name":"` + name + `"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/XML" {
+			w.Write([]byte(`<a><name>` + name[:len(name)-9] + `</name></a>`))
+			return
+		}
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	type named struct {
+		Name string `json:"name" xml:"name"`
+	}
+	helpers := map[string]func(c Client, res *http.Response) error{
+		"Bytes": func(c Client, res *http.Response) error { _, err := readBodyWithStatus(res); return err },
+		"Text":  func(c Client, res *http.Response) error { _, err := c.(*client).Text(res); return err },
+		"JSON":  func(c Client, res *http.Response) error { _, err := JSON[named](res); return err },
+		"XML":   func(c Client, res *http.Response) error { _, err := XML[named](res); return err },
+	}
+
+	tests := []struct {
+		name    string
+		config  int64
+		request int64
+		wantErr bool
+	}{
+		{"unlimited", 0, 0, false},
+		{"config limit", 10, 0, true},
+		{"limit equals size", int64(len(payload)), 0, false},
+		{"request limit", 0, 10, true},
+		{"request overrides config", 10, 1 << 20, false},
+	}
+	for _, tt := range tests {
+		for helper, read := range helpers {
+			t.Run(tt.name+"/"+helper, func(t *testing.T) {
+				c := New(&Config{MaxResponseBytes: tt.config})
+				defer c.Close(context.Background())
+
+				res, err := c.Get(srv.URL+"/"+helper, WithMaxResponseBytes(tt.request))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = read(c, res)
+				if tt.wantErr {
+					var tooLarge *BodyTooLargeError
+					if !errors.As(err, &tooLarge) {
+						t.Fatalf("err = %v, want *BodyTooLargeError", err)
+					}
+					if !errors.Is(err, ErrResponseTooLarge) {
+						t.Error("err does not match ErrResponseTooLarge")
+					}
+					limit := max(tt.config, tt.request)
+					if tooLarge.Limit != limit || tooLarge.Read != limit+1 {
+						t.Errorf("err = %+v, want limit %d and read %d", tooLarge, limit, limit+1)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestMaxResponseBytesCountsDecodedBytes(t *testing.T) {
+	plain := bytes.Repeat([]byte("a"), 1000)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(plain)
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	// The compressed body fits, the decoded one does not
+	limit := int64(compressed.Len() + 1)
+
+	for _, acceptEncoding := range []string{"", "gzip"} {
+		t.Run("Accept-Encoding="+acceptEncoding, func(t *testing.T) {
+			c := New(&Config{MaxResponseBytes: limit})
+			defer c.Close(context.Background())
+
+			// An explicit Accept-Encoding makes httpx decode instead of the transport
+			var opts []Option
+			if acceptEncoding != "" {
+				opts = append(opts, WithHeaders(http.Header{"Accept-Encoding": {acceptEncoding}}))
+			}
+
+			res, err := c.Get(srv.URL, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readBodyWithStatus(res); !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("err = %v, want ErrResponseTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestMaxResponseBytesTruncatesDecodedErrorBody(t *testing.T) {
+	srv := encodedServer(t, "deflate", http.StatusInternalServerError, deflated(t, 1000))
+
+	c := New(&Config{MaxResponseBytes: 100})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readBodyWithStatus(res)
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %v, want *HttpError", err)
+	}
+	if !bytes.Equal(httpErr.Body, make([]byte, 100)) {
+		t.Errorf("HttpError.Body = %q, want 100 decoded bytes", httpErr.Body)
+	}
+}
+
+func TestEmptyEncodedBody(t *testing.T) {
+	srv := encodedServer(t, "gzip", http.StatusOK, nil)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithHeaders(http.Header{"Accept-Encoding": {"gzip"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil || len(body) != 0 {
+		t.Errorf("body = %q, err = %v, want empty body", body, err)
+	}
+}
+
+func TestRequireBody(t *testing.T) {
+	srv := encodedServer(t, "", http.StatusOK, nil)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	helpers := map[string]func(res *http.Response) error{
+		"JSON":      func(res *http.Response) error { _, err := JSON[user](res); return err },
+		"XML":       func(res *http.Response) error { _, err := XML[user](res); return err },
+		"DecodeAny": func(res *http.Response) error { _, err := DecodeAny[user](res); return err },
+		"ReadJSON":  func(res *http.Response) error { var u user; return c.(*client).ReadJSON(res, &u) },
+		"ReadXML":   func(res *http.Response) error { var u user; return c.(*client).ReadXML(res, &u) },
+	}
+	for name, decode := range helpers {
+		t.Run(name, func(t *testing.T) {
+			// JSON decoding of an empty body is lenient by default
+			if strings.Contains(name, "JSON") {
+				res, err := c.Get(srv.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := decode(res); err != nil {
+					t.Errorf("without WithRequireBody: err = %v", err)
+				}
+			}
+
+			res, err := c.Get(srv.URL, WithRequireBody())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := decode(res); !errors.Is(err, ErrEmptyBody) {
+				t.Errorf("with WithRequireBody: err = %v, want ErrEmptyBody", err)
+			}
+		})
+	}
+
+	// Reading raw bytes never requires a body
+	res, err := c.Get(srv.URL, WithRequireBody())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.(*client).Bytes(res); err != nil {
+		t.Errorf("Bytes: err = %v", err)
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		want  []string
+	}{
+		{"missing", nil, nil},
+		{"empty", []string{""}, nil},
+		{"single", []string{"GET"}, []string{"GET"}},
+		{"normalized", []string{" get ,Post,,  "}, []string{"GET", "POST"}},
+		{"duplicates across lines", []string{"GET, HEAD", "head, OPTIONS"}, []string{"GET", "HEAD", "OPTIONS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{"Allow": tt.allow}}
+			if got := AllowedMethods(res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowedMethods = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieMap(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Set-Cookie", "session=abc; Path=/; HttpOnly")
+		w.Header().Add("Set-Cookie", "theme=dark")
+		w.Header().Add("Set-Cookie", "session=def; Max-Age=60")
+		w.Header().Add("Set-Cookie", "invalid")
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// The last Set-Cookie for a name wins
+	want := map[string]string{"session": "def", "theme": "dark"}
+	if got := CookieMap(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("CookieMap = %v, want %v", got, want)
+	}
+
+	if got := CookieMap(&http.Response{Header: http.Header{}}); len(got) != 0 {
+		t.Errorf("CookieMap without cookies = %v", got)
+	}
+}
+
+func TestHelpersReplayBody(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	const body = `<user><name>John</name></user>`
+	srv := contentServer(t, "application/xml", body)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+	cl := c.(*client)
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every helper sees the same bytes, in any order and more than once
+	for i := range 2 {
+		if b, err := cl.Bytes(res); err != nil || string(b) != body {
+			t.Errorf("round %d: Bytes = %q, %v", i, b, err)
+		}
+		if s, err := cl.Text(res); err != nil || s != body {
+			t.Errorf("round %d: Text = %q, %v", i, s, err)
+		}
+		var u user
+		if err := cl.ReadXML(res, &u); err != nil || u.Name != "John" {
+			t.Errorf("round %d: ReadXML = %+v, %v", i, u, err)
+		}
+		if u, err := XML[user](res); err != nil || u.Name != "John" {
+			t.Errorf("round %d: XML = %+v, %v", i, u, err)
+		}
+		// The body is not JSON; the decode error does not consume it
+		if err := cl.ReadJSON(res, &u); err == nil {
+			t.Errorf("round %d: ReadJSON decoded XML", i)
+		}
+		if _, err := JSON[user](res); err == nil {
+			t.Errorf("round %d: JSON decoded XML", i)
+		}
+	}
+
+	// Download consumes the body; helpers called afterwards cannot replay it
+	res, err = c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := Download(res, &buf, nil); err != nil || buf.String() != body {
+		t.Fatalf("Download = %q, %v", buf.String(), err)
+	}
+	if _, err := cl.Bytes(res); !errors.Is(err, ErrBodyAlreadyConsumed) {
+		t.Errorf("Bytes after Download: err = %v", err)
+	}
+}
+
+func TestHelpersReplayError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"missing"}`))
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+	cl := c.(*client)
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed response keeps returning the same HttpError
+	var first *HttpError
+	if _, err := cl.Bytes(res); !errors.As(err, &first) {
+		t.Fatalf("err = %v, want an HttpError", err)
+	}
+	reads := []func() error{
+		func() error { _, err := cl.Text(res); return err },
+		func() error { var v map[string]string; return cl.ReadJSON(res, &v) },
+		func() error { _, err := JSON[map[string]string](res); return err },
+		func() error { _, err := cl.Bytes(res); return err },
+	}
+	for i, read := range reads {
+		var httpErr *HttpError
+		if err := read(); !errors.As(err, &httpErr) {
+			t.Fatalf("read %d: err = %v, want an HttpError", i, err)
+		}
+		if httpErr.StatusCode != http.StatusNotFound || string(httpErr.Body) != string(first.Body) {
+			t.Errorf("read %d: %d %q, want %d %q", i, httpErr.StatusCode, httpErr.Body, first.StatusCode, first.Body)
+		}
+	}
+}
+
+func TestHandBuiltResponse(t *testing.T) {
+	type user struct{ Name string }
+
+	// Recorders and caches build responses without a request
+	ok := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"Name":"John"}`)),
+	}
+	if u, err := JSON[user](ok); err != nil || u.Name != "John" {
+		t.Errorf("JSON = %+v, %v", u, err)
+	}
+
+	failed := &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Status:     "502 Bad Gateway",
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{},
+		Trailer:    http.Header{"X-Checksum": {"abc"}},
+		Body:       io.NopCloser(strings.NewReader("upstream down")),
+	}
+	_, err := JSON[user](failed)
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %v, want an HttpError", err)
+	}
+	if httpErr.Method != "" || httpErr.URL != "" || httpErr.Proto != "HTTP/1.1" || string(httpErr.Body) != "upstream down" {
+		t.Errorf("HttpError = %+v", httpErr)
+	}
+	if httpErr.Response != failed || httpErr.Response.Trailer.Get("X-Checksum") != "abc" {
+		t.Errorf("Response = %v, want the original with its trailers", httpErr.Response)
+	}
+	if msg := httpErr.Error(); !strings.Contains(msg, "502") {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	// No body at all decodes as empty
+	if _, err := JSON[*user](&http.Response{StatusCode: http.StatusNoContent}); err != nil {
+		t.Errorf("nil body: %v", err)
+	}
+}
+
+func TestHttpErrorStatusHelpers(t *testing.T) {
+	helpers := map[string]func(*HttpError) bool{
+		"BadRequest":         (*HttpError).IsBadRequest,
+		"Unauthorized":       (*HttpError).IsUnauthorized,
+		"Forbidden":          (*HttpError).IsForbidden,
+		"NotFound":           (*HttpError).IsNotFound,
+		"Conflict":           (*HttpError).IsConflict,
+		"PreconditionFailed": (*HttpError).IsPreconditionFailed,
+		"TooManyRequests":    (*HttpError).IsTooManyRequests,
+		"ClientError":        (*HttpError).IsClientError,
+		"ServerError":        (*HttpError).IsServerError,
+	}
+	tests := map[int][]string{
+		http.StatusBadRequest:          {"BadRequest", "ClientError"},
+		http.StatusUnauthorized:        {"Unauthorized", "ClientError"},
+		http.StatusForbidden:           {"Forbidden", "ClientError"},
+		http.StatusNotFound:            {"NotFound", "ClientError"},
+		http.StatusConflict:            {"Conflict", "ClientError"},
+		http.StatusPreconditionFailed:  {"PreconditionFailed", "ClientError"},
+		http.StatusTooManyRequests:     {"TooManyRequests", "ClientError"},
+		http.StatusTeapot:              {"ClientError"},
+		http.StatusInternalServerError: {"ServerError"},
+		http.StatusServiceUnavailable:  {"ServerError"},
+		http.StatusMultipleChoices:     nil,
+	}
+	for code, want := range tests {
+		err := &HttpError{StatusCode: code}
+		for name, is := range helpers {
+			if got := is(err); got != slices.Contains(want, name) {
+				t.Errorf("%d: Is%s = %v", code, name, got)
+			}
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = JSON[map[string]any](res)
+
+	// The status survives wrapping by the caller
+	wrapped := fmt.Errorf("loading user: %w", fmt.Errorf("repository: %w", err))
+	if code, ok := StatusCode(wrapped); !ok || code != http.StatusNotFound {
+		t.Errorf("StatusCode = %d, %v", code, ok)
+	}
+	var httpErr *HttpError
+	if !errors.As(wrapped, &httpErr) || !httpErr.IsNotFound() {
+		t.Errorf("wrapped error %v is not a 404", wrapped)
+	}
+
+	for _, err := range []error{nil, errors.New("dial failed"), fmt.Errorf("wrapped: %w", context.Canceled)} {
+		if code, ok := StatusCode(err); ok || code != 0 {
+			t.Errorf("StatusCode(%v) = %d, %v", err, code, ok)
+		}
+	}
+}
+
+func TestStrictJSON(t *testing.T) {
+	srv := contentServer(t, "application/json", `{"name":"Ada","nickname":"ada"}`)
+
+	helpers := map[string]func(c Client, res *http.Response) (decodedUser, error){
+		"ReadJSON": func(c Client, res *http.Response) (decodedUser, error) {
+			var user decodedUser
+			err := c.(*client).ReadJSON(res, &user)
+			return user, err
+		},
+		"JSON": func(_ Client, res *http.Response) (decodedUser, error) {
+			return JSON[decodedUser](res)
+		},
+	}
+
+	tests := []struct {
+		name   string
+		config bool
+		opts   []Option
+		strict bool
+	}{
+		{"lenient by default", false, nil, false},
+		{"Config.StrictJSON", true, nil, true},
+		{"WithStrictJSON on a lenient client", false, []Option{WithStrictJSON()}, true},
+	}
+	for _, tt := range tests {
+		for helper, decode := range helpers {
+			t.Run(tt.name+"/"+helper, func(t *testing.T) {
+				c := New(&Config{StrictJSON: tt.config})
+				defer c.Close(context.Background())
+
+				res, err := c.Get(srv.URL, tt.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				user, err := decode(c, res)
+
+				if !tt.strict {
+					if err != nil || user.Name != "Ada" {
+						t.Errorf("got %+v, %v; want the unknown field ignored", user, err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), `unknown field "nickname"`) {
+					t.Errorf("err = %v, want the unknown field rejected", err)
+				}
+			})
+		}
+	}
+
+	// The option only affects its own request
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	if res, err := c.Get(srv.URL, WithStrictJSON()); err != nil {
+		t.Fatal(err)
+	} else if _, err := JSON[decodedUser](res); err == nil {
+		t.Error("strict request accepted the unknown field")
+	}
+	if res, err := c.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	} else if _, err := JSON[decodedUser](res); err != nil {
+		t.Errorf("next request is strict too: %v", err)
+	}
+}
+
+func TestIsSuccess(t *testing.T) {
+	notModified := encodedServer(t, "", http.StatusNotModified, nil)
+	created := encodedServer(t, "", http.StatusCreated, []byte(`{"name":"Ada"}`))
+	missing := encodedServer(t, "", http.StatusNotFound, []byte(`{"name":"Ada"}`))
+
+	// 304 counts as success, 201 does not
+	c := New(&Config{IsSuccess: func(status int) bool {
+		return status == http.StatusOK || status == http.StatusNotModified
+	}})
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		srv     *httptest.Server
+		wantErr bool
+	}{
+		{notModified, false},
+		{created, true},
+		{missing, true},
+	}
+	for _, tt := range tests {
+		res, err := c.Get(tt.srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = JSON[decodedUser](res)
+		var httpErr *HttpError
+		if got := errors.As(err, &httpErr); got != tt.wantErr {
+			t.Errorf("status %d: err = %v, want HttpError %v", res.StatusCode, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRawStatus(t *testing.T) {
+	srv := encodedServer(t, "", http.StatusUnprocessableEntity, []byte(`{"name":"invalid"}`))
+
+	tests := []struct {
+		name    string
+		config  bool
+		opts    []Option
+		wantErr bool
+	}{
+		{"default", false, nil, true},
+		{"Config.RawStatus", true, nil, false},
+		{"WithRawStatus", false, []Option{WithRawStatus()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(&Config{RawStatus: tt.config})
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := JSON[decodedUser](res)
+			if tt.wantErr {
+				var httpErr *HttpError
+				if !errors.As(err, &httpErr) {
+					t.Errorf("err = %v, want an HttpError", err)
+				}
+				return
+			}
+			if err != nil || user.Name != "invalid" {
+				t.Errorf("got %+v, %v; want the 422 body decoded", user, err)
+			}
+		})
+	}
+}
+
+func TestErrorAs(t *testing.T) {
+	type apiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   apiError
+		wantOK bool
+	}{
+		{"structured error", http.StatusConflict, `{"code":"taken","message":"name in use"}`, apiError{"taken", "name in use"}, true},
+		{"empty body", http.StatusInternalServerError, "", apiError{}, false},
+		{"not JSON", http.StatusBadGateway, "<html>bad gateway</html>", apiError{}, false},
+		{"success", http.StatusOK, `{"code":"ok"}`, apiError{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := encodedServer(t, "", tt.status, []byte(tt.body))
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			res, err := c.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Wrapping keeps the HttpError reachable
+			_, err = JSON[apiError](res)
+			got, ok := ErrorAs[apiError](fmt.Errorf("creating user: %w", err))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ErrorAs = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package httpx
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryConfig controls automatic retries of failed requests.
+//
+// A request is retried when the transport returns an error or when the server
+// answers with 429 Too Many Requests, 502 Bad Gateway, 503 Service Unavailable
+// or 504 Gateway Timeout. Only idempotent requests are retried: GET, HEAD,
+// OPTIONS, TRACE, PUT and DELETE, and requests of any method that carry an
+// Idempotency-Key or X-Idempotency-Key header. Retries are disabled when
+// Config.Retry is nil.
+//
+// Typical usage:
+//
+//	client := httpx.New(&httpx.Config{
+//	    Retry: &httpx.RetryConfig{
+//	        MaxRetries:    3,
+//	        Backoff:       200 * time.Millisecond,
+//	        MaxRetryAfter: 10 * time.Second,
+//	    },
+//	})
+type RetryConfig struct {
+	// MaxRetries is the number of additional attempts after the first one.
+	MaxRetries int
+
+	// Backoff is the base delay before the first retry. It doubles after each
+	// further attempt. A value of 0 uses a default of 100ms.
+	Backoff time.Duration
+
+	// MaxRetryAfter caps the delay a server may request via the Retry-After
+	// header on 429 and 503 responses. When the advised delay is larger, the
+	// response is returned immediately instead of waiting. A value of 0 uses a
+	// default of 30s.
+	MaxRetryAfter time.Duration
+
+	// MaxRetryDelay caps the computed exponential backoff, so that high
+	// attempt counts do not sleep for minutes. A value of 0 leaves the
+	// backoff uncapped.
+	MaxRetryDelay time.Duration
+}
+
+// backoff returns the computed delay before the given retry attempt
+// (zero-based), capped at MaxRetryDelay.
+func (r *RetryConfig) backoff(attempt int) time.Duration {
+	base := r.Backoff
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+
+	limit := r.MaxRetryDelay
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+
+	// Stop doubling before the delay passes the cap or overflows
+	delay := base
+	for i := 0; i < attempt && delay < limit; i++ {
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+	}
+
+	return min(delay, limit)
+}
+
+// maxRetryAfter returns the effective Retry-After cap.
+func (r *RetryConfig) maxRetryAfter() time.Duration {
+	if r.MaxRetryAfter <= 0 {
+		return 30 * time.Second
+	}
+	return r.MaxRetryAfter
+}
+
+// maxDrainBytes bounds how much of a discarded response body is read to let
+// the connection be reused. Longer bodies are closed unread instead, which
+// costs the connection but not an unbounded download.
+const maxDrainBytes = 64 << 10
+
+// idempotent reports whether req can be sent again without repeating side
+// effects: its logical method is idempotent (RFC 9110, section 9.2.2) or it
+// carries an idempotency key, the same rule net/http applies.
+func idempotent(req *http.Request) bool {
+	switch LogicalMethod(req) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, key := req.Header["Idempotency-Key"]
+	_, xKey := req.Header["X-Idempotency-Key"]
+	return key || xKey
+}
+
+// shouldRetry reports whether a request outcome is worth another attempt.
+func shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter extracts the server-advised delay from a 429 or 503 response.
+// The Retry-After header may hold either a number of seconds or an HTTP date.
+// The boolean result is false when no usable delay is present.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res.StatusCode != http.StatusTooManyRequests &&
+		res.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// send executes req with the given http.Client and applies the retry
+// policy. When retrying is not possible or not worthwhile, the last response
+// is returned untouched so the response helpers can turn it into an HttpError.
+func (c *client) send(httpClient *http.Client, req *http.Request, retry *RetryConfig) (*http.Response, error) {
+	res, err := httpClient.Do(req)
+
+	// Repeating non-idempotent requests could repeat their side effects
+	if retry == nil || !idempotent(req) {
+		return res, err
+	}
+
+	for attempt := 0; attempt < retry.MaxRetries; attempt++ {
+		if !shouldRetry(res, err) {
+			break
+		}
+
+		// Bodies without GetBody cannot be replayed
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			break
+		}
+
+		delay := retry.backoff(attempt)
+
+		// Prefer the server-advised delay over the computed backoff
+		if res != nil {
+			if advised, ok := retryAfter(res); ok {
+				if advised > retry.maxRetryAfter() {
+					break
+				}
+				delay = advised
+			}
+		}
+
+		// Sleeping past the context deadline is pointless
+		ctx := req.Context()
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			break
+		}
+
+		// Release the previous attempt before reusing the request
+		if res != nil {
+			io.CopyN(io.Discard, res.Body, maxDrainBytes)
+			res.Body.Close()
+		}
+
+		phaseTrackerFrom(ctx).enter(phaseRetryBackoff)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+
+		res, err = httpClient.Do(req)
+	}
+
+	return res, err
+}
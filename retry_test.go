@@ -0,0 +1,177 @@
+package httpx
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryOnlyIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		opts   []Option
+		want   int64
+	}{
+		{"GET", http.MethodGet, nil, 3},
+		{"PUT", http.MethodPut, nil, 3},
+		{"DELETE", http.MethodDelete, nil, 3},
+		{"POST", http.MethodPost, nil, 1},
+		{"PATCH", http.MethodPatch, nil, 1},
+		{"POST with Idempotency-Key", http.MethodPost, []Option{
+			WithHeaders(http.Header{"Idempotency-Key": {"order-42"}}),
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			c := New(&Config{Retry: &RetryConfig{MaxRetries: 2, Backoff: time.Millisecond}})
+			defer c.Close(context.Background())
+
+			res, err := c.Do(tt.method, srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if n := calls.Load(); n != tt.want {
+				t.Errorf("server called %d times, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDrainIsBounded(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			return
+		}
+
+		// The failed attempt streams an endless body
+		w.WriteHeader(http.StatusServiceUnavailable)
+		chunk := make([]byte, 32<<10)
+		for r.Context().Err() == nil {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("retry stuck draining the failed attempt: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("status %d after %d calls, want 200 after 2", res.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 1, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	started := time.Now()
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("retried after %v, want the advised second", elapsed)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := &RetryConfig{Backoff: 100 * time.Millisecond, MaxRetryDelay: time.Second}
+
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for attempt, d := range want {
+		if got := r.backoff(attempt); got != d*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, d*time.Millisecond)
+		}
+	}
+}
+
+func TestRetryBackoffNeverExceedsCap(t *testing.T) {
+	tests := map[string]*RetryConfig{
+		"capped":         {Backoff: 100 * time.Millisecond, MaxRetryDelay: 5 * time.Second},
+		"cap below base": {Backoff: time.Second, MaxRetryDelay: 10 * time.Millisecond},
+		"uncapped":       {Backoff: time.Hour},
+	}
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			limit := r.MaxRetryDelay
+			if limit <= 0 {
+				limit = math.MaxInt64
+			}
+
+			// Doubling must neither pass the cap nor overflow
+			prev := time.Duration(0)
+			for attempt := range 1000 {
+				got := r.backoff(attempt)
+				if got <= 0 || got > limit || got < prev {
+					t.Fatalf("backoff(%d) = %v after %v, cap %v", attempt, got, prev, limit)
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestWithMaxRetryDelay(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 3, Backoff: time.Minute, MaxRetryDelay: time.Hour}})
+	defer c.Close(context.Background())
+
+	// The request caps the client's minute-long backoff
+	started := time.Now()
+	res, err := c.Get(srv.URL, WithMaxRetryDelay(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK || calls.Load() != 4 {
+		t.Errorf("status %d after %d calls", res.StatusCode, calls.Load())
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("retries took %v", elapsed)
+	}
+}
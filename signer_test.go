@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestSigV4Vectors checks SigV4Signer against the AWS Signature Version 4
+// test suite (aws-sig-v4-test-suite).
+func TestSigV4Vectors(t *testing.T) {
+	signer := &SigV4Signer{
+		Region:          "us-east-1",
+		Service:         "service",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	const credential = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "
+
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		headers   http.Header
+		body      string
+		signed    string
+		signature string
+	}{
+		{"get-vanilla", "GET", "/", nil, "", "host;x-amz-date",
+			"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-empty-query-key", "GET", "/?Param1=value1", nil, "", "host;x-amz-date",
+			"a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb"},
+		{"get-vanilla-query-order-key-case", "GET", "/?Param2=value2&Param1=value1", nil, "", "host;x-amz-date",
+			"b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"get-unreserved", "GET", "/-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", nil, "", "host;x-amz-date",
+			"07ef7494c76fa4850883e2b006601f940f8a34d404d0cfa977f52a65bbf5f24f"},
+		{"get-utf8", "GET", "/ሴ", nil, "", "host;x-amz-date",
+			"8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85"},
+		{"get-space", "GET", "/example%20space/", nil, "", "host;x-amz-date",
+			"652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741"},
+		{"get-relative-relative", "GET", "/example1/example2/../..", nil, "", "host;x-amz-date",
+			"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-slashes", "GET", "//", nil, "", "host;x-amz-date",
+			"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"post-vanilla", "POST", "/", nil, "", "host;x-amz-date",
+			"5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"post-vanilla-query", "POST", "/?Param1=value1", nil, "", "host;x-amz-date",
+			"28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11"},
+		{"post-header-key-case", "POST", "/", http.Header{"My-Header1": {"value1"}}, "", "host;my-header1;x-amz-date",
+			"c5410059b04c1ee005303aed430f6e6645f61f4dc9e1461ec8f8916fdf18852c"},
+		{"post-x-www-form-urlencoded", "POST", "/", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, "Param1=value1",
+			"content-type;host;x-amz-date",
+			"ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, "https://example.amazonaws.com"+tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+			req.Header.Set("X-Amz-Date", "20150830T123600Z")
+
+			sum := sha256.Sum256([]byte(tt.body))
+			if err := signer.Sign(req, hex.EncodeToString(sum[:])); err != nil {
+				t.Fatal(err)
+			}
+
+			want := credential + "SignedHeaders=" + tt.signed + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSigV4S3Headers(t *testing.T) {
+	signer := &SigV4Signer{Region: "us-east-1", Service: "s3", AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+
+	req, err := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/a//b.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "httpx")
+	if err := signer.Sign(req, UnsignedPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	// S3 signs the payload hash and the session token, and keeps the path as is
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != UnsignedPayload {
+		t.Errorf("X-Amz-Content-Sha256 = %q", got)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q", got)
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %s", auth)
+	}
+	if got := signer.canonicalPath(req.URL); got != "/a//b.txt" {
+		t.Errorf("canonical path = %q, want it unnormalized", got)
+	}
+}
@@ -0,0 +1,467 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a Server-Sent Event as parsed from a text/event-stream body.
+type Event struct {
+	ID    string        // last event ID seen on the stream, kept across events
+	Type  string        // event name, "message" unless the server set one
+	Data  string        // data lines joined with "\n"
+	Retry time.Duration // last reconnection time announced, zero if none
+}
+
+// EventStream delivers the events of a text/event-stream response on a
+// channel. The channel is closed when the stream ends, its context is
+// cancelled or Close is called; Err then reports why.
+type EventStream struct {
+	events chan Event
+	parent context.Context // cancellation of the caller, reported by Err
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error // set before done is closed
+}
+
+// Events returns the channel the parsed events arrive on.
+func (s *EventStream) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns the error that ended the stream once the events channel is
+// closed: nil when the server closed the stream or Close was called, the
+// context error on cancellation, or the read error otherwise.
+func (s *EventStream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the stream, closes the response body and waits until the
+// events channel is closed. It is safe to call more than once.
+func (s *EventStream) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// ErrNotEventStream is returned by ReadEvents for responses whose
+// Content-Type is not text/event-stream.
+var ErrNotEventStream = errors.New("httpx: response is not an event stream")
+
+// ReadEvents parses the text/event-stream body of res in the background and
+// delivers the events on the returned stream's channel, following the SSE
+// specification: multi-line data fields are joined with "\n", comment lines
+// (":ping") are skipped and blocks without data are not dispatched. The
+// stream stops when ctx is cancelled, Close is called, the server closes
+// the connection or the client that sent the request is closed; the response
+// body is closed in all cases. Lines are limited to 1 MB unless the request
+// was sent with WithMaxLineBytes.
+//
+// The reading goroutine is listed by the client's Tasks and awaited by its
+// Close. Once the client is closed, ReadEvents returns ErrClientClosed.
+//
+// Non-2xx responses return an HttpError and other Content-Types an error
+// wrapping ErrNotEventStream. Since the body is streamed, the client's
+// RequestTimeout ends long-lived streams; send the request with
+// WithNoTimeout or WithIdleTimeout.
+//
+// Example:
+//
+//	res, err := client.Get(url, httpx.WithNoTimeout())
+//	if err != nil {
+//	    return err
+//	}
+//	stream, err := httpx.ReadEvents(ctx, res)
+//	if err != nil {
+//	    return err
+//	}
+//	defer stream.Close()
+//	for event := range stream.Events() {
+//	    fmt.Println(event.Type, event.Data)
+//	}
+//	return stream.Err()
+func ReadEvents(ctx context.Context, res *http.Response) (*EventStream, error) {
+	body, err := eventStreamBody(res)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, ctx := newEventStream(ctx)
+
+	read := func(taskCtx context.Context) {
+		stop := context.AfterFunc(taskCtx, stream.cancel)
+		defer stop()
+
+		stream.finish(ctx, consumeEvents(ctx, res, body, &eventParser{}, stream.events))
+	}
+
+	// Responses not sent by httpx have no client to track the goroutine
+	tasks := readOptionsFor(res).tasks
+	if tasks == nil {
+		go read(context.Background())
+		return stream, nil
+	}
+
+	if !tasks.spawn("read-events "+res.Request.URL.String(), read) {
+		body.Close()
+		res.Body.Close()
+		stream.cancel()
+		return nil, ErrClientClosed
+	}
+
+	return stream, nil
+}
+
+// defaultEventRetry is the reconnection delay of Client.Stream until the
+// server announces one with a retry field.
+const defaultEventRetry = 3 * time.Second
+
+// Stream opens a Server-Sent Events stream at url with GET and delivers its
+// events in the background, e.g. for OpenAI-style streaming APIs. It sends
+// Accept: text/event-stream unless set via WithHeaders, and lifts the
+// client-wide timeouts like WithNoTimeout.
+//
+// When the connection drops or the server closes it, Stream reconnects after
+// the delay of the last retry field (3 seconds by default), sending the last
+// event ID as Last-Event-ID, as browsers do. Reconnecting stops for good on a
+// non-2xx response, another Content-Type or 204 No Content, which end the
+// stream; Err then reports the reason.
+//
+// Errors of the first connection are returned directly. The stream ends when
+// the context passed with WithContext is cancelled, Close is called or the
+// client is closed.
+//
+// Example:
+//
+//	stream, err := client.Stream("https://api.com/v1/completions/stream",
+//	    httpx.WithContext(ctx),
+//	    httpx.WithBearerToken(key),
+//	)
+//	if err != nil {
+//	    return err
+//	}
+//	defer stream.Close()
+//	for event := range stream.Events() {
+//	    fmt.Print(event.Data)
+//	}
+//	return stream.Err()
+func (c *client) Stream(url string, opts ...Option) (*EventStream, error) {
+	stream, ctx := newEventStream(buildOptions(opts).Context)
+	opts = append(opts[:len(opts):len(opts)], WithContext(ctx), WithNoTimeout())
+
+	res, body, err := c.connectEvents(url, opts, "")
+	if err != nil {
+		stream.cancel()
+		return nil, err
+	}
+
+	started := c.tasks.spawn("event-stream "+url, func(taskCtx context.Context) {
+		stop := context.AfterFunc(taskCtx, stream.cancel)
+		defer stop()
+
+		stream.finish(ctx, c.followEvents(ctx, url, opts, res, body, stream.events))
+	})
+	if !started {
+		body.Close()
+		res.Body.Close()
+		stream.cancel()
+		return nil, ErrClientClosed
+	}
+
+	return stream, nil
+}
+
+// followEvents consumes the first connection of Stream and reconnects until
+// ctx is done or reconnecting must stop. It returns the error that ended the
+// stream, io.EOF when the server asked to stop with 204 No Content.
+func (c *client) followEvents(ctx context.Context, url string, opts []Option, res *http.Response, body io.ReadCloser, out chan<- Event) error {
+	p := &eventParser{}
+
+	for {
+		if res.StatusCode == http.StatusNoContent {
+			body.Close()
+			res.Body.Close()
+			return io.EOF
+		}
+
+		err := consumeEvents(ctx, res, body, p, out)
+		if ctx.Err() != nil || errors.Is(err, bufio.ErrTooLong) {
+			return err
+		}
+
+		// Network errors are retried after the same delay
+		for {
+			delay := p.retry
+			if delay == 0 {
+				delay = defaultEventRetry
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+
+			res, body, err = c.connectEvents(url, opts, p.lastID)
+			var httpErr *HttpError
+			if errors.As(err, &httpErr) || errors.Is(err, ErrNotEventStream) {
+				return err
+			}
+			if err == nil {
+				break
+			}
+		}
+	}
+}
+
+// connectEvents sends the GET request of Stream, resuming after lastID if
+// set, and returns the response with its event stream body.
+func (c *client) connectEvents(url string, opts []Option, lastID string) (*http.Response, io.ReadCloser, error) {
+	opts = append(opts[:len(opts):len(opts)], withEventStreamHeaders(lastID))
+
+	res, err := c.Get(url, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := eventStreamBody(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, body, nil
+}
+
+// withEventStreamHeaders sets Accept: text/event-stream unless the request
+// has an Accept header, and Last-Event-ID when lastID is set. The header map
+// passed to WithHeaders is not modified.
+func withEventStreamHeaders(lastID string) Option {
+	return func(o *RequestOptions) {
+		h := o.Headers.Clone()
+		if h == nil {
+			h = make(http.Header)
+		}
+		if h.Get("Accept") == "" {
+			h.Set("Accept", "text/event-stream")
+		}
+		if lastID != "" {
+			h.Set("Last-Event-ID", lastID)
+		}
+		o.Headers = h
+	}
+}
+
+// eventStreamBody checks that res is a successful text/event-stream
+// response and returns its body for streaming. On error the body is closed.
+func eventStreamBody(res *http.Response) (io.ReadCloser, error) {
+	if readOptionsFor(res).failed(res.StatusCode) {
+		_, err := readBodyWithStatus(res)
+		return nil, err
+	}
+
+	// 204 No Content tells Stream to stop reconnecting
+	if res.StatusCode == http.StatusNoContent {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/event-stream" {
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: Content-Type %q", ErrNotEventStream, contentType)
+	}
+
+	return streamBody(res)
+}
+
+// newEventStream returns an open stream whose context derives from parent.
+func newEventStream(parent context.Context) (*EventStream, context.Context) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	return &EventStream{
+		events: make(chan Event),
+		parent: parent,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}, ctx
+}
+
+// finish records why the stream ended and closes its channel. ctx is the
+// stream context, cancelled by Close.
+func (s *EventStream) finish(ctx context.Context, err error) {
+	switch {
+	case s.parent.Err() != nil:
+		s.err = s.parent.Err()
+	case ctx.Err() != nil, errors.Is(err, io.EOF):
+		// Closed by the caller or by the server
+	default:
+		s.err = err
+	}
+
+	s.cancel()
+	close(s.events)
+	close(s.done)
+}
+
+// consumeEvents reads the events of one connection and closes its body.
+// Cancelling ctx unblocks a pending read.
+func consumeEvents(ctx context.Context, res *http.Response, body io.ReadCloser, p *eventParser, out chan<- Event) error {
+	stop := context.AfterFunc(ctx, func() {
+		body.Close()
+		res.Body.Close()
+	})
+
+	err := readEvents(ctx, body, readOptionsFor(res).maxLineBytes(), p, out)
+	if stop() {
+		body.Close()
+		res.Body.Close()
+	}
+	return err
+}
+
+// readEvents parses r line by line and sends the dispatched events to out
+// until r is exhausted or ctx is done. Lines longer than maxLine bytes fail
+// the stream. It returns io.EOF when the stream ended normally.
+func readEvents(ctx context.Context, r io.Reader, maxLine int, p *eventParser, out chan<- Event) error {
+	scanner := lineScanner(r, maxLine)
+	scanner.Split(scanEventLines)
+
+	for scanner.Scan() {
+		event, ok := p.line(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		select {
+		case out <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("httpx: reading event stream: %w", lineError(err, maxLine))
+	}
+	return io.EOF
+}
+
+// eventParser holds the state of the SSE parsing algorithm between lines.
+type eventParser struct {
+	started bool // first line seen, a leading BOM is stripped
+	lastID  string
+	retry   time.Duration
+
+	eventType string
+	data      strings.Builder
+	hasData   bool
+}
+
+// line processes one line of the stream and returns the event it
+// dispatches, if any.
+func (p *eventParser) line(line string) (Event, bool) {
+	if !p.started {
+		p.started = true
+		line = strings.TrimPrefix(line, "\uFEFF")
+	}
+
+	// A blank line dispatches the event built so far
+	if line == "" {
+		return p.dispatch()
+	}
+
+	// Comments, e.g. keep-alive pings
+	if strings.HasPrefix(line, ":") {
+		return Event{}, false
+	}
+
+	field, value, found := strings.Cut(line, ":")
+	if found {
+		value = strings.TrimPrefix(value, " ")
+	}
+
+	switch field {
+	case "event":
+		p.eventType = value
+	case "data":
+		if p.hasData {
+			p.data.WriteByte('\n')
+		}
+		p.data.WriteString(value)
+		p.hasData = true
+	case "id":
+		if !strings.ContainsRune(value, 0) {
+			p.lastID = value
+		}
+	case "retry":
+		if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+			p.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return Event{}, false
+}
+
+// dispatch completes the current event and resets the per-event fields.
+// Events without data are dropped, as the specification requires.
+func (p *eventParser) dispatch() (Event, bool) {
+	defer func() {
+		p.eventType = ""
+		p.data.Reset()
+		p.hasData = false
+	}()
+
+	if !p.hasData {
+		return Event{}, false
+	}
+
+	event := Event{ID: p.lastID, Type: p.eventType, Data: p.data.String(), Retry: p.retry}
+	if event.Type == "" {
+		event.Type = "message"
+	}
+	return event, true
+}
+
+// scanEventLines is a bufio.SplitFunc for the line endings of SSE: "\r\n",
+// "\n" or a lone "\r".
+func scanEventLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A "\r" at the end of the buffer may be followed by "\n"
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+
+	// A final line without terminator is incomplete and ignored
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
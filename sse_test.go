@@ -0,0 +1,217 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// eventServer writes body as an event stream and keeps the connection open
+// until the client goes away when hold is set.
+func eventServer(t *testing.T, body string, hold bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(body))
+		w.(http.Flusher).Flush()
+		if hold {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReadEvents(t *testing.T) {
+	srv := eventServer(t, ": ping\n\nid: 1\nevent: greeting\ndata: hello\ndata: world\n\nretry: 1500\n\ndata: bye\r\n\r\n", false)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL, WithNoTimeout())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := ReadEvents(context.Background(), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var got []Event
+	for event := range stream.Events() {
+		got = append(got, event)
+	}
+	want := []Event{
+		{ID: "1", Type: "greeting", Data: "hello\nworld"},
+		{ID: "1", Type: "message", Data: "bye", Retry: 1500 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+	if err := stream.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestReadEventsNotEventStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+	}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadEvents(context.Background(), res); !errors.Is(err, ErrNotEventStream) {
+		t.Errorf("err = %v, want ErrNotEventStream", err)
+	}
+}
+
+func TestReadEventsIsTrackedByClient(t *testing.T) {
+	srv := eventServer(t, "data: one\n\n", true)
+
+	c := New(nil)
+
+	res, err := c.Get(srv.URL, WithNoTimeout())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := ReadEvents(context.Background(), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-stream.Events()
+
+	tasks := c.Tasks()
+	if len(tasks) != 1 || !strings.HasPrefix(tasks[0].Name, "read-events ") {
+		t.Fatalf("Tasks() = %v, want the read-events task", tasks)
+	}
+
+	// Closing the client ends the stream
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, open := <-stream.Events(); open {
+		t.Error("events channel still open after Close")
+	}
+	if tasks := c.Tasks(); len(tasks) != 0 {
+		t.Errorf("Tasks() after Close = %v", tasks)
+	}
+}
+
+func TestReadEventsAfterClientClose(t *testing.T) {
+	srv := eventServer(t, "data: one\n\n", false)
+
+	c := New(nil)
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close(context.Background())
+
+	if _, err := ReadEvents(context.Background(), res); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("err = %v, want ErrClientClosed", err)
+	}
+}
+
+// resumingServer drops the first connection after event 2 and records the
+// Last-Event-ID of the reconnect, then serves event 3 and holds the stream.
+func resumingServer(t *testing.T) (*httptest.Server, chan string) {
+	t.Helper()
+
+	resumed := make(chan string, 1)
+	var connections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if connections.Add(1) == 1 {
+			w.Write([]byte("retry: 10\n\nid: 1\ndata: one\n\nid: 2\ndata: two\n\n"))
+			return
+		}
+
+		select {
+		case resumed <- r.Header.Get("Last-Event-ID"):
+		default:
+		}
+		w.Write([]byte("id: 3\ndata: three\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, resumed
+}
+
+func TestStreamReconnects(t *testing.T) {
+	for _, stop := range []string{"Close", "context"} {
+		t.Run(stop, func(t *testing.T) {
+			srv, resumed := resumingServer(t)
+
+			c := New(nil)
+			defer c.Close(context.Background())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream, err := c.Stream(srv.URL, WithContext(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+
+			var got []string
+			for len(got) < 3 {
+				event, open := <-stream.Events()
+				if !open {
+					t.Fatalf("stream ended after %v: %v", got, stream.Err())
+				}
+				got = append(got, event.ID+":"+event.Data)
+			}
+			if want := []string{"1:one", "2:two", "3:three"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("events = %v, want %v", got, want)
+			}
+			if id := <-resumed; id != "2" {
+				t.Errorf("reconnect sent Last-Event-ID %q, want 2", id)
+			}
+
+			if stop == "Close" {
+				stream.Close()
+			} else {
+				cancel()
+			}
+
+			select {
+			case _, open := <-stream.Events():
+				if open {
+					t.Fatal("event delivered after the stream was stopped")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("events channel not closed")
+			}
+			if err := stream.Err(); (stop == "context") != errors.Is(err, context.Canceled) {
+				t.Errorf("Err() = %v after stopping via %s", err, stop)
+			}
+
+			// The background task exits right after closing the channel
+			deadline := time.Now().Add(time.Second)
+			for len(c.Tasks()) > 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if tasks := c.Tasks(); len(tasks) != 0 {
+				t.Errorf("Tasks() = %v, want none", tasks)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package httpx
+
+// RequestStats holds diagnostics collected while a single request is
+// executed. Callers receive it by passing a pointer through WithStats.
+type RequestStats struct {
+	// FreshConnection reports whether the request was sent over a newly
+	// dialed connection rather than one reused from the idle pool.
+	FreshConnection bool
+
+	// ContentEncoding is the encoding applied to the request body by the
+	// compression policy, or empty when the body was sent uncompressed.
+	ContentEncoding string
+
+	// UncompressedBytes and CompressedBytes are the request body sizes before
+	// and after compression. Both are zero when no policy applied.
+	UncompressedBytes int64
+	CompressedBytes   int64
+}
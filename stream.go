@@ -0,0 +1,141 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+// bufferStream reads a non-seekable stream fully into memory so it can be
+// replayed on retries. Closable streams are closed afterwards, mirroring what
+// the transport does with a streamed body. Reading stops once ctx is done.
+func bufferStream(ctx context.Context, r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(&contextReader{ctx: ctx, r: r})
+
+	if closer, ok := r.(io.Closer); ok {
+		closer.Close()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("httpx: buffering request body for retries: %w", err)
+	}
+
+	// A non-nil slice keeps empty streams recognizable as a body
+	if b == nil {
+		b = []byte{}
+	}
+
+	return b, nil
+}
+
+// streamLength determines the remaining size of r when the caller did not
+// supply one. Readers reporting Len() and regular files are recognized;
+// anything else returns -1.
+func streamLength(r io.Reader) int64 {
+	if l, ok := r.(interface{ Len() int }); ok {
+		return int64(l.Len())
+	}
+
+	if f, ok := r.(interface {
+		Stat() (fs.FileInfo, error)
+		io.Seeker
+	}); ok {
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+
+	return -1
+}
+
+// prepareStream configures req for a streamed body. A known length, either
+// supplied or detected via streamLength, sets Content-Length; otherwise the
+// body is sent with chunked transfer encoding. Seekable readers get a GetBody
+// func so redirects and retries can rewind them; other readers are sent
+// exactly once.
+func prepareStream(req *http.Request, r io.Reader, length int64) {
+	if length <= 0 {
+		length = streamLength(r)
+	}
+
+	// A zero length would make the transport drop the body entirely
+	if length > 0 {
+		req.ContentLength = length
+	}
+
+	if req.GetBody != nil {
+		return
+	}
+
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return
+	}
+
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}
+}
+
+// ownedStream reads a stream owned by httpx (see WithBodyStream). It hides
+// Close from the transport, so the stream stays usable for rewinds until do
+// closes it, and records the first read error of the source.
+type ownedStream struct {
+	source io.Reader
+
+	mu  sync.Mutex
+	err error
+}
+
+// Read implements io.Reader.
+func (s *ownedStream) Read(p []byte) (int, error) {
+	n, err := s.source.Read(p)
+	if err != nil && err != io.EOF {
+		s.mu.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.mu.Unlock()
+	}
+	return n, err
+}
+
+// sourceErr returns the first read error of the source, if any. The
+// transport may still be reading, hence the lock.
+func (s *ownedStream) sourceErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// contextReader fails reads once ctx is done, so that draining a large
+// stream stops promptly when the caller gives up.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
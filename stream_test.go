@@ -0,0 +1,335 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers the first failures requests with 503 and echoes the
+// body of the others, reporting the Content-Length it saw.
+func flakyServer(t testing.TB, failures int64) *httptest.Server {
+	t.Helper()
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		w.Header().Set("X-Attempts", strconv.FormatInt(calls.Load(), 10))
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// onlyReader hides every method but Read, so the body can neither be
+// measured nor rewound.
+type onlyReader struct{ io.Reader }
+
+func TestStreamBodyWithoutRetries(t *testing.T) {
+	srv := flakyServer(t, 0)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Put(srv.URL, WithBody(onlyReader{strings.NewReader("streamed")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unbuffered bodies of unknown size are sent chunked
+	if string(body) != "streamed" || res.Header.Get("X-Content-Length") != "-1" {
+		t.Errorf("server got %q with Content-Length %s", body, res.Header.Get("X-Content-Length"))
+	}
+}
+
+func TestStreamBodyIsReplayedOnRetry(t *testing.T) {
+	tests := map[string]func() io.Reader{
+		"buffered reader": func() io.Reader { return onlyReader{strings.NewReader("replayed")} },
+		"seekable reader": func() io.Reader { return strings.NewReader("replayed") },
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := flakyServer(t, 2)
+
+			c := New(&Config{Retry: &RetryConfig{MaxRetries: 2, Backoff: time.Millisecond}})
+			defer c.Close(context.Background())
+
+			res, err := c.Put(srv.URL, WithBody(body()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readBodyWithStatus(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != "replayed" || res.Header.Get("X-Attempts") != "3" {
+				t.Errorf("attempt %s got %q", res.Header.Get("X-Attempts"), got)
+			}
+			if res.Header.Get("X-Content-Length") != "8" {
+				t.Errorf("Content-Length = %s, want the replayable size", res.Header.Get("X-Content-Length"))
+			}
+		})
+	}
+}
+
+func BenchmarkStreamBody(b *testing.B) {
+	payload := strings.Repeat("x", 1<<20)
+
+	tests := map[string]*Config{
+		"no retries": nil,
+		"retries":    {Retry: &RetryConfig{MaxRetries: 2}},
+	}
+	for name, cfg := range tests {
+		b.Run(name, func(b *testing.B) {
+			srv := flakyServer(b, 0)
+
+			c := New(cfg)
+			defer c.Close(context.Background())
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(payload)))
+			for range b.N {
+				res, err := c.Put(srv.URL, WithBody(onlyReader{strings.NewReader(payload)}))
+				if err != nil {
+					b.Fatal(err)
+				}
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
+		})
+	}
+}
+
+// closeCounter counts how often the wrapped body is closed.
+type closeCounter struct {
+	io.ReadCloser
+	closes atomic.Int32
+}
+
+func (c *closeCounter) Close() error {
+	c.closes.Add(1)
+	return c.ReadCloser.Close()
+}
+
+// failingBody returns data, then err.
+type failingBody struct {
+	data string
+	err  error
+}
+
+func (b *failingBody) Read(p []byte) (int, error) {
+	if b.data == "" {
+		return 0, b.err
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
+
+func (b *failingBody) Close() error { return nil }
+
+// activeConns tracks the connections of srv that are serving a request.
+func activeConns(srv *httptest.Server) func() int {
+	var mu sync.Mutex
+	states := map[net.Conn]http.ConnState{}
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		states[conn] = state
+	}
+
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, state := range states {
+			if state == http.StateActive {
+				n++
+			}
+		}
+		return n
+	}
+}
+
+func TestWithBodyStreamProxy(t *testing.T) {
+	payload := strings.Repeat("proxied ", 10000)
+
+	origin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	active := activeConns(origin)
+	origin.Start()
+	defer origin.Close()
+
+	mirror := flakyServer(t, 0)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		wantErr bool
+	}{
+		{"success", http.MethodPut, mirror.URL, false},
+		{"invalid URL", http.MethodPut, "://mirror", true},
+		{"unreachable", http.MethodPut, down.URL, true},
+		{"body rejected before sending", http.MethodGet, mirror.URL, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := c.Get(origin.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := &closeCounter{ReadCloser: src.Body}
+
+			res, err := c.Do(tt.method, tt.target, WithBodyStream(body, src.ContentLength))
+			if tt.wantErr {
+				if err == nil {
+					res.Body.Close()
+					t.Fatal("request succeeded")
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := readBodyWithStatus(res)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != payload {
+					t.Errorf("mirror got %d bytes, want %d", len(got), len(payload))
+				}
+			}
+
+			if n := body.closes.Load(); n != 1 {
+				t.Errorf("source closed %d times, want once", n)
+			}
+		})
+	}
+
+	// Every origin connection was released
+	deadline := time.Now().Add(time.Second)
+	for active() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := active(); n > 0 {
+		t.Errorf("%d origin connections still active", n)
+	}
+}
+
+func TestWithBodyStreamSourceError(t *testing.T) {
+	srv := flakyServer(t, 0)
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	errSource := errors.New("origin reset")
+	body := &closeCounter{ReadCloser: &failingBody{data: "partial", err: errSource}}
+
+	_, err := c.Put(srv.URL, WithBodyStream(body, -1))
+	if !errors.Is(err, errSource) || !strings.Contains(err.Error(), "reading request body") {
+		t.Errorf("err = %v, want the source error", err)
+	}
+	if n := body.closes.Load(); n != 1 {
+		t.Errorf("source closed %d times, want once", n)
+	}
+}
+
+func TestWithBodyStreamAbandonedRetries(t *testing.T) {
+	srv := flakyServer(t, 10)
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 2, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	body := &closeCounter{ReadCloser: io.NopCloser(strings.NewReader("retried"))}
+	res, err := c.Put(srv.URL, WithBodyStream(body, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the last failed attempt", res.StatusCode)
+	}
+	if n := body.closes.Load(); n != 1 {
+		t.Errorf("source closed %d times, want once", n)
+	}
+}
+
+func TestWithBodyReaderFactory(t *testing.T) {
+	srv := flakyServer(t, 2)
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 2, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	var calls atomic.Int32
+	factory := func() (io.Reader, error) {
+		n := calls.Add(1)
+		return onlyReader{strings.NewReader("upload " + strconv.Itoa(int(n)))}, nil
+	}
+
+	res, err := c.Put(srv.URL, WithBodyReaderFactory(factory, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := readBodyWithStatus(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every attempt streamed a fresh reader instead of a buffered copy
+	if string(body) != "upload 3" || calls.Load() != 3 {
+		t.Errorf("server got %q after %d factory calls", body, calls.Load())
+	}
+	if got := res.Header.Get("X-Content-Length"); got != "-1" {
+		t.Errorf("Content-Length = %s, want a streamed body", got)
+	}
+}
+
+func TestWithBodyReaderFactoryError(t *testing.T) {
+	srv := flakyServer(t, 1)
+
+	c := New(&Config{Retry: &RetryConfig{MaxRetries: 2, Backoff: time.Millisecond}})
+	defer c.Close(context.Background())
+
+	errFactory := errors.New("source gone")
+	var calls atomic.Int32
+	factory := func() (io.Reader, error) {
+		if calls.Add(1) > 1 {
+			return nil, errFactory
+		}
+		return strings.NewReader("first"), nil
+	}
+
+	res, err := c.Put(srv.URL, WithBodyReaderFactory(factory, -1))
+	if err == nil {
+		res.Body.Close()
+	}
+	if !errors.Is(err, errFactory) {
+		t.Errorf("err = %v, want the factory error", err)
+	}
+}
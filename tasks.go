@@ -0,0 +1,142 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TaskInfo describes a background goroutine spawned by the client.
+type TaskInfo struct {
+	ID      uint64    // Unique identifier within the client
+	Name    string    // Descriptive name, e.g. "token-refresh"
+	Started time.Time // When the goroutine was spawned
+}
+
+// taskTracker registers every goroutine the client spawns so that Close can
+// wait for them and Tasks can list them for leak hunting.
+type taskTracker struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	nextID  uint64
+	running map[uint64]TaskInfo
+	closed  bool
+
+	// ctx is cancelled by Close to signal shutdown to all tasks.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newTaskTracker returns an empty tracker ready for use.
+func newTaskTracker() *taskTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &taskTracker{
+		running: make(map[uint64]TaskInfo),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// spawn runs fn in a tracked goroutine. The context passed to fn is cancelled
+// when the client is closed. After Close, spawn refuses new tasks and returns
+// false.
+func (t *taskTracker) spawn(name string, fn func(ctx context.Context)) bool {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return false
+	}
+
+	t.nextID++
+	id := t.nextID
+	t.running[id] = TaskInfo{ID: id, Name: name, Started: time.Now()}
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.running, id)
+			t.mu.Unlock()
+			t.wg.Done()
+		}()
+
+		fn(t.ctx)
+	}()
+
+	return true
+}
+
+// list returns a snapshot of the running tasks ordered by ID.
+func (t *taskTracker) list() []TaskInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tasks := make([]TaskInfo, 0, len(t.running))
+	for _, info := range t.running {
+		tasks = append(tasks, info)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// shutdown signals all tasks to stop and waits until they have exited or ctx
+// is done. Repeated calls are safe.
+func (t *taskTracker) shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	t.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		remaining := t.list()
+		names := make([]string, len(remaining))
+		for i, info := range remaining {
+			names[i] = info.Name
+		}
+		return fmt.Errorf("httpx: %d task(s) still running after close (%s): %w",
+			len(remaining), strings.Join(names, ", "), ctx.Err())
+	}
+}
+
+// Close stops all background goroutines spawned by the client and waits for
+// them to exit, bounded by ctx. Idle connections are closed as well. The
+// client must not be used after Close.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//	    log.Println(err)
+//	}
+func (c *client) Close(ctx context.Context) error {
+	err := c.tasks.shutdown(ctx)
+
+	c.httpClient.CloseIdleConnections()
+	c.freshClient.CloseIdleConnections()
+	c.idleEngine.CloseIdleConnections()
+	c.idleFresh.CloseIdleConnections()
+
+	return err
+}
+
+// Tasks lists the background goroutines currently running on behalf of the
+// client. It is intended for debugging goroutine leaks.
+func (c *client) Tasks() []TaskInfo {
+	return c.tasks.list()
+}
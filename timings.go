@@ -0,0 +1,127 @@
+package httpx
+
+import (
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTimings holds the connection diagnostics of a request, as reported
+// to the callback of WithTimings. Phases that did not happen, e.g. DNS and
+// dialing on a reused connection, are zero. With retries, the values
+// describe the last attempt.
+type RequestTimings struct {
+	DNSLookup       time.Duration // Resolving the host name
+	TCPConnect      time.Duration // Dialing the connection
+	TLSHandshake    time.Duration // TLS handshake
+	TimeToFirstByte time.Duration // From the start of the attempt to the first response byte
+	Total           time.Duration // From the start of the attempt until the call returned
+	ConnReused      bool          // Whether an idle connection was reused
+}
+
+// timingsTracer collects RequestTimings through httptrace.
+type timingsTracer struct {
+	mu        sync.Mutex
+	timings   RequestTimings
+	start     time.Time
+	dnsStart  time.Time
+	dialStart time.Time
+	tlsStart  time.Time
+}
+
+// newTimingsTracer returns a tracer fed by the client trace of trackPhases.
+// Its event methods do nothing on a nil tracer.
+func newTimingsTracer() *timingsTracer {
+	return &timingsTracer{start: time.Now()}
+}
+
+// getConn starts a new attempt.
+func (t *timingsTracer) getConn() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = RequestTimings{}
+	t.start = time.Now()
+}
+
+// startDNS and endDNS measure DNSLookup.
+func (t *timingsTracer) startDNS() {
+	if t != nil {
+		t.mark(&t.dnsStart)
+	}
+}
+
+func (t *timingsTracer) endDNS() {
+	if t != nil {
+		t.measure(&t.dnsStart, &t.timings.DNSLookup)
+	}
+}
+
+// startDial and endDial measure TCPConnect of successful dials.
+func (t *timingsTracer) startDial() {
+	if t != nil {
+		t.mark(&t.dialStart)
+	}
+}
+
+func (t *timingsTracer) endDial(err error) {
+	if t != nil && err == nil {
+		t.measure(&t.dialStart, &t.timings.TCPConnect)
+	}
+}
+
+// startTLS and endTLS measure TLSHandshake of successful handshakes.
+func (t *timingsTracer) startTLS() {
+	if t != nil {
+		t.mark(&t.tlsStart)
+	}
+}
+
+func (t *timingsTracer) endTLS(err error) {
+	if t != nil && err == nil {
+		t.measure(&t.tlsStart, &t.timings.TLSHandshake)
+	}
+}
+
+// gotConn records whether the connection was reused.
+func (t *timingsTracer) gotConn(info httptrace.GotConnInfo) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings.ConnReused = info.Reused
+}
+
+// gotFirstResponseByte measures TimeToFirstByte.
+func (t *timingsTracer) gotFirstResponseByte() {
+	if t != nil {
+		t.measure(&t.start, &t.timings.TimeToFirstByte)
+	}
+}
+
+// mark records the current time in at.
+func (t *timingsTracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+// measure stores the time elapsed since start in d.
+func (t *timingsTracer) measure(start *time.Time, d *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*d = time.Since(*start)
+}
+
+// report passes the collected timings to fn.
+func (t *timingsTracer) report(fn func(RequestTimings)) {
+	t.mu.Lock()
+	timings := t.timings
+	timings.Total = time.Since(t.start)
+	t.mu.Unlock()
+
+	fn(timings)
+}
@@ -0,0 +1,174 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCollectTimings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(&Config{CollectTimings: true})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	timings := Timings(res)
+	if len(timings) == 0 {
+		t.Fatal("Timings(res) is empty")
+	}
+	phases := map[string]bool{}
+	for _, p := range timings {
+		phases[p.Phase] = true
+	}
+	for _, want := range []string{phaseDialing, phaseWaitingHeaders} {
+		if !phases[want] {
+			t.Errorf("Timings(res) = %v, missing %q", timings, want)
+		}
+	}
+
+	res, err = c.Get(srv.URL + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = readBodyWithStatus(res)
+
+	var httpErr *HttpError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("err = %v, want *HttpError", err)
+	}
+	if len(httpErr.Timings) == 0 {
+		t.Error("HttpError.Timings is empty")
+	}
+}
+
+func TestTimingsNotCollectedByDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if timings := Timings(res); timings != nil {
+		t.Errorf("Timings(res) = %v, want nil", timings)
+	}
+}
+
+func TestWithTimingsAndStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	for i, wantReused := range []bool{false, true} {
+		var timings RequestTimings
+		var stats RequestStats
+
+		res, err := c.Get(srv.URL,
+			WithTimings(func(t RequestTimings) { timings = t }),
+			WithStats(&stats),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		readBodyWithStatus(res)
+
+		if timings.ConnReused != wantReused || stats.FreshConnection == wantReused {
+			t.Errorf("request %d: reused %v, fresh %v; want reused %v", i+1, timings.ConnReused, stats.FreshConnection, wantReused)
+		}
+		if timings.TimeToFirstByte <= 0 || timings.Total < timings.TimeToFirstByte {
+			t.Errorf("request %d: timings = %+v", i+1, timings)
+		}
+		if !wantReused && timings.TCPConnect <= 0 {
+			t.Errorf("request %d: TCPConnect = %v, want the dial time", i+1, timings.TCPConnect)
+		}
+	}
+}
+
+func TestWithTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := New(&Config{CollectTimings: true})
+	defer c.Close(context.Background())
+
+	for i, wantReused := range []bool{false, true} {
+		var mu sync.Mutex
+		var events []string
+		record := func(event string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}
+		trace := &httptrace.ClientTrace{
+			ConnectDone:          func(string, string, error) { record("connect") },
+			GotConn:              func(info httptrace.GotConnInfo) { record(fmt.Sprintf("conn reused=%v", info.Reused)) },
+			GotFirstResponseByte: func() { record("first byte") },
+		}
+
+		var timings RequestTimings
+		res, err := c.Get(srv.URL, WithTrace(trace), WithTimings(func(t RequestTimings) { timings = t }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		// The caller's hooks fire alongside the ones httpx installs
+		want := []string{fmt.Sprintf("conn reused=%v", wantReused), "first byte"}
+		if !wantReused {
+			want = append([]string{"connect"}, want...)
+		}
+		mu.Lock()
+		if !slices.Equal(events, want) {
+			t.Errorf("request %d: trace events = %v, want %v", i+1, events, want)
+		}
+		mu.Unlock()
+
+		if timings.ConnReused != wantReused || timings.TimeToFirstByte <= 0 {
+			t.Errorf("request %d: timings = %+v", i+1, timings)
+		}
+		if len(Timings(res)) == 0 {
+			t.Errorf("request %d: phases not collected next to the trace", i+1)
+		}
+	}
+}
+
+func BenchmarkRequestTracing(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	b.ReportAllocs()
+	for range b.N {
+		res, err := c.Get(srv.URL)
+		if err != nil {
+			b.Fatal(err)
+		}
+		readBodyWithStatus(res)
+	}
+}
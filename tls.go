@@ -0,0 +1,68 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsConfig builds the transport TLS configuration from the TLS-related
+// Config fields. It returns nil when none is set, keeping Go's defaults.
+func (cfg *Config) tlsConfig() (*tls.Config, error) {
+	if cfg.TLS == nil && cfg.ClientCertFile == "" && cfg.ClientKeyFile == "" &&
+		cfg.RootCAFile == "" && !cfg.InsecureSkipVerify &&
+		cfg.MinTLSVersion == 0 && cfg.MaxTLSVersion == 0 && len(cfg.CipherSuites) == 0 {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.TLS != nil {
+		tlsConfig = cfg.TLS.Clone()
+	}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		if cfg.ClientCertFile == "" || cfg.ClientKeyFile == "" {
+			return nil, fmt.Errorf("httpx: ClientCertFile and ClientKeyFile must be set together")
+		}
+
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("httpx: loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+
+	if cfg.RootCAFile != "" {
+		pem, err := os.ReadFile(cfg.RootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("httpx: reading root CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("httpx: no PEM certificates found in %s", cfg.RootCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if cfg.MinTLSVersion != 0 && cfg.MaxTLSVersion != 0 && cfg.MinTLSVersion > cfg.MaxTLSVersion {
+		return nil, fmt.Errorf("httpx: MinTLSVersion %s is above MaxTLSVersion %s",
+			tls.VersionName(cfg.MinTLSVersion), tls.VersionName(cfg.MaxTLSVersion))
+	}
+	if cfg.MinTLSVersion != 0 {
+		tlsConfig.MinVersion = cfg.MinTLSVersion
+	}
+	if cfg.MaxTLSVersion != 0 {
+		tlsConfig.MaxVersion = cfg.MaxTLSVersion
+	}
+	if len(cfg.CipherSuites) > 0 {
+		tlsConfig.CipherSuites = cfg.CipherSuites
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,226 @@
+package httpx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block of the given type to a file in dir.
+func writePEM(t testing.TB, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCertificate creates a self-signed client certificate and returns
+// its parsed form with the paths of its certificate and key files.
+func clientCertificate(t testing.TB, dir string) (cert *x509.Certificate, certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "httpx test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func TestRootCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	// The test CA is not trusted by default
+	untrusting := New(nil)
+	defer untrusting.Close(context.Background())
+	if _, err := untrusting.Get(srv.URL); err == nil {
+		t.Error("request to a server with an unknown CA succeeded")
+	}
+
+	trusting, err := NewClient(&Config{RootCAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trusting.Close(context.Background())
+
+	res, err := trusting.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cert, certFile, keyFile := clientCertificate(t, dir)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Client", r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	anonymous := New(&Config{RootCAFile: caFile})
+	defer anonymous.Close(context.Background())
+	if _, err := anonymous.Get(srv.URL); err == nil {
+		t.Error("request without a client certificate succeeded")
+	}
+
+	c, err := NewClient(&Config{RootCAFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Client"); got != "httpx test client" {
+		t.Errorf("server saw client %q", got)
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.txt")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0o600)
+
+	tests := map[string]*Config{
+		"missing key":         {ClientCertFile: "client.pem"},
+		"missing files":       {ClientCertFile: filepath.Join(dir, "a.pem"), ClientKeyFile: filepath.Join(dir, "b.pem")},
+		"missing CA file":     {RootCAFile: filepath.Join(dir, "ca.pem")},
+		"CA file without PEM": {RootCAFile: notPEM},
+		"min above max":       {MinTLSVersion: tls.VersionTLS13, MaxTLSVersion: tls.VersionTLS12},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewClient(cfg); err == nil {
+				t.Error("NewClient succeeded")
+			}
+		})
+	}
+}
+
+func TestTLSAudit(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	tests := []struct {
+		name   string
+		config Config
+		want   TLSObservation
+	}{
+		{
+			"TLS 1.2 with a fixed suite",
+			Config{
+				MaxTLSVersion: tls.VersionTLS12,
+				CipherSuites:  []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			},
+			TLSObservation{Version: tls.VersionTLS12, CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		},
+		{
+			"TLS 1.3",
+			Config{MinTLSVersion: tls.VersionTLS13},
+			TLSObservation{Version: tls.VersionTLS13},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var observed []TLSObservation
+
+			cfg := tt.config
+			cfg.RootCAFile = caFile
+			cfg.TLSAudit = func(o TLSObservation) {
+				mu.Lock()
+				defer mu.Unlock()
+				observed = append(observed, o)
+			}
+			c, err := NewClient(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close(context.Background())
+
+			// New handshakes with the same parameters are reported once
+			for range 3 {
+				res, err := c.Get(srv.URL, WithFreshConnection())
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(observed) != 1 {
+				t.Fatalf("observations = %v, want one", observed)
+			}
+			got := observed[0]
+			if got.Host != srv.Listener.Addr().String() || got.Version != tt.want.Version {
+				t.Errorf("observed %s, want host %s and %s", got, srv.Listener.Addr(), tls.VersionName(tt.want.Version))
+			}
+			if tt.want.CipherSuite != 0 && got.CipherSuite != tt.want.CipherSuite {
+				t.Errorf("cipher suite = %s, want %s", tls.CipherSuiteName(got.CipherSuite), tls.CipherSuiteName(tt.want.CipherSuite))
+			}
+			if tls.CipherSuiteName(got.CipherSuite) == fmt.Sprintf("0x%04X", got.CipherSuite) {
+				t.Errorf("cipher suite %#04x is unknown", got.CipherSuite)
+			}
+			if got.PeerCertificate == nil || !got.PeerCertificate.Equal(srv.Certificate()) {
+				t.Error("observation does not carry the server certificate")
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TLSObservation is a combination of host, TLS version and cipher suite
+// seen on a connection, as reported to Config.TLSAudit, together with the
+// certificate the server presented as evidence.
+type TLSObservation struct {
+	Host        string // host[:port] of the request URL
+	Version     uint16 // negotiated version, e.g. tls.VersionTLS13
+	CipherSuite uint16 // negotiated cipher suite, e.g. tls.TLS_AES_128_GCM_SHA256
+
+	// PeerCertificate is the leaf certificate of the first connection with
+	// this combination. It does not make observations distinct.
+	PeerCertificate *x509.Certificate
+}
+
+// String returns e.g. "api.com TLS 1.3 TLS_AES_128_GCM_SHA256".
+func (o TLSObservation) String() string {
+	return o.Host + " " + tls.VersionName(o.Version) + " " + tls.CipherSuiteName(o.CipherSuite)
+}
+
+// tlsAuditor reports every new TLSObservation once.
+type tlsAuditor struct {
+	report func(TLSObservation)
+	seen   sync.Map // TLSObservation without certificate -> struct{}
+}
+
+// observe reports the TLS parameters of a response from host unless they
+// were seen before.
+func (a *tlsAuditor) observe(host string, cs *tls.ConnectionState) {
+	observed := TLSObservation{Host: host, Version: cs.Version, CipherSuite: cs.CipherSuite}
+	if _, loaded := a.seen.LoadOrStore(observed, struct{}{}); loaded {
+		return
+	}
+
+	if len(cs.PeerCertificates) > 0 {
+		observed.PeerCertificate = cs.PeerCertificates[0]
+	}
+	a.report(observed)
+}
+
+// TLSPolicyError is returned when a TLS handshake fails in a way the
+// MinTLSVersion, MaxTLSVersion or CipherSuites policy of the client can
+// explain. ServerVersion is the version the server offered, zero when the
+// handshake did not reveal it.
+type TLSPolicyError struct {
+	Host          string
+	MinVersion    uint16 // Config.MinTLSVersion, zero if unset
+	MaxVersion    uint16 // Config.MaxTLSVersion, zero if unset
+	ServerVersion uint16
+	Err           error
+}
+
+// Error implements the error interface.
+func (e *TLSPolicyError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "httpx: TLS handshake with %s rejected by policy (", e.Host)
+	switch {
+	case e.MinVersion != 0 && e.MaxVersion != 0:
+		fmt.Fprintf(&b, "%s to %s", tls.VersionName(e.MinVersion), tls.VersionName(e.MaxVersion))
+	case e.MinVersion != 0:
+		fmt.Fprintf(&b, "minimum %s", tls.VersionName(e.MinVersion))
+	case e.MaxVersion != 0:
+		fmt.Fprintf(&b, "maximum %s", tls.VersionName(e.MaxVersion))
+	default:
+		b.WriteString("restricted cipher suites")
+	}
+	b.WriteString(")")
+
+	if e.ServerVersion != 0 {
+		fmt.Fprintf(&b, ", server offered %s", tls.VersionName(e.ServerVersion))
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+
+	return b.String()
+}
+
+// Unwrap returns the transport error.
+func (e *TLSPolicyError) Unwrap() error {
+	return e.Err
+}
+
+// tlsPolicyFailures are the handshake errors of crypto/tls that a version
+// or cipher suite mismatch produces.
+var tlsPolicyFailures = []string{
+	"tls: server selected unsupported protocol version",
+	"tls: server chose an unconfigured cipher suite",
+	"remote error: tls: protocol version not supported",
+	"remote error: tls: handshake failure",
+	"remote error: tls: insufficient security level",
+}
+
+// tlsPolicyError wraps handshake failures of req in a TLSPolicyError when
+// the client restricts TLS versions or cipher suites. Other errors are
+// returned as is.
+func (c *client) tlsPolicyError(err error, req *http.Request) error {
+	if err == nil || c.Transport != nil ||
+		(c.MinTLSVersion == 0 && c.MaxTLSVersion == 0 && len(c.CipherSuites) == 0) {
+		return err
+	}
+
+	msg := err.Error()
+	for _, failure := range tlsPolicyFailures {
+		i := strings.Index(msg, failure)
+		if i < 0 {
+			continue
+		}
+
+		policyErr := &TLSPolicyError{
+			Host:       req.URL.Host,
+			MinVersion: c.MinTLSVersion,
+			MaxVersion: c.MaxTLSVersion,
+			Err:        err,
+		}
+
+		// e.g. "tls: server selected unsupported protocol version 301"
+		var offered uint16
+		if _, scanErr := fmt.Sscanf(msg[i+len(failure):], " %x", &offered); scanErr == nil {
+			policyErr.ServerVersion = offered
+		}
+
+		return policyErr
+	}
+
+	return err
+}
@@ -0,0 +1,228 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Token is an access token sent in the Authorization header.
+type Token struct {
+	// AccessToken is the credential itself.
+	AccessToken string
+
+	// TokenType is the authorization scheme, defaulting to "Bearer".
+	TokenType string
+
+	// Expiry is when the token stops being valid. A zero value never expires.
+	Expiry time.Time
+}
+
+// TokenRefresher keeps an access token fresh in the background. Each token is
+// refreshed ahead of its expiry by Margin plus a random share of Jitter, so a
+// fleet of instances holding the same token does not refresh it at the same
+// instant. Tokens that live shorter than that are refreshed halfway through
+// their lifetime.
+//
+// Only one refresh runs at a time; requests that find no valid token wait for
+// it instead of fetching their own. The background goroutine stops when the
+// client is closed.
+//
+// Example:
+//
+//	client := httpx.New(&httpx.Config{
+//	    TokenRefresher: &httpx.TokenRefresher{
+//	        Fetch: func(ctx context.Context) (httpx.Token, error) {
+//	            return auth.Login(ctx)
+//	        },
+//	        Margin: time.Minute,
+//	        Jitter: 30 * time.Second,
+//	    },
+//	})
+type TokenRefresher struct {
+	// Fetch obtains a new token. It is required; NewClient fails without it.
+	Fetch func(ctx context.Context) (Token, error)
+
+	// Margin is how long before expiry a token is refreshed at the latest.
+	// Defaults to 30 seconds.
+	Margin time.Duration
+
+	// Jitter is the maximum random time added to Margin. Defaults to 30
+	// seconds; a negative value disables jitter.
+	Jitter time.Duration
+
+	// RetryInterval is the wait after a failed background refresh. Defaults
+	// to 5 seconds.
+	RetryInterval time.Duration
+}
+
+// margin returns the configured or default refresh margin.
+func (r *TokenRefresher) margin() time.Duration {
+	if r.Margin > 0 {
+		return r.Margin
+	}
+	return 30 * time.Second
+}
+
+// jitter returns a random duration in [0, Jitter).
+func (r *TokenRefresher) jitter() time.Duration {
+	limit := r.Jitter
+	if limit == 0 {
+		limit = 30 * time.Second
+	}
+	if limit < 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
+
+// retryInterval returns the configured or default wait after a failure.
+func (r *TokenRefresher) retryInterval() time.Duration {
+	if r.RetryInterval > 0 {
+		return r.RetryInterval
+	}
+	return 5 * time.Second
+}
+
+// tokenManager holds the current token of a client and serializes refreshes.
+type tokenManager struct {
+	refresher *TokenRefresher
+
+	refreshMu sync.Mutex // held while Fetch runs
+
+	mu        sync.Mutex
+	token     Token
+	refreshAt time.Time // see refreshTime, zero if never
+}
+
+// newTokenManager returns a manager for r without a token.
+func newTokenManager(r *TokenRefresher) *tokenManager {
+	return &tokenManager{refresher: r}
+}
+
+// valid reports whether the current token can still be sent. Callers hold mu.
+func (m *tokenManager) valid(now time.Time) bool {
+	return m.token.AccessToken != "" && (m.token.Expiry.IsZero() || now.Before(m.token.Expiry))
+}
+
+// due reports whether the current token should be refreshed proactively.
+// Callers hold mu.
+func (m *tokenManager) due(now time.Time) bool {
+	return m.token.AccessToken == "" || (!m.refreshAt.IsZero() && !now.Before(m.refreshAt))
+}
+
+// authorization returns the Authorization header value for a request,
+// fetching a token first when none is valid.
+func (m *tokenManager) authorization(ctx context.Context) (string, error) {
+	token, err := m.refresh(ctx, m.valid)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := token.TokenType
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	return scheme + " " + token.AccessToken, nil
+}
+
+// refresh returns the current token, fetching a new one unless fresh
+// reports that the current token is good enough. fresh is called with mu
+// held. The check is repeated after acquiring refreshMu, so concurrent
+// callers share a single fetch.
+func (m *tokenManager) refresh(ctx context.Context, fresh func(now time.Time) bool) (Token, error) {
+	current := func() (Token, bool) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.token, fresh(time.Now())
+	}
+
+	if token, good := current(); good {
+		return token, nil
+	}
+
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	if token, good := current(); good {
+		return token, nil
+	}
+
+	token, err := m.refresher.Fetch(ctx)
+	if err != nil {
+		return Token{}, fmt.Errorf("httpx: refreshing token: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.token = token
+	m.refreshAt = time.Time{}
+	if !token.Expiry.IsZero() {
+		m.refreshAt = m.refreshTime(time.Now(), token.Expiry)
+	}
+
+	return token, nil
+}
+
+// refreshTime returns when a token fetched at now and expiring at expiry is
+// due: margin and jitter ahead of expiry, or halfway through its lifetime
+// when the token lives shorter than that, so that short-lived tokens are not
+// due the moment they arrive.
+func (m *tokenManager) refreshTime(now, expiry time.Time) time.Time {
+	lifetime := expiry.Sub(now)
+	lead := m.refresher.margin() + m.refresher.jitter()
+	if lead >= lifetime {
+		return now.Add(lifetime / 2)
+	}
+	return expiry.Add(-lead)
+}
+
+// invalidate discards token if it is still the current one, so that the
+// next caller fetches a new token.
+func (m *tokenManager) invalidate(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token.AccessToken == token {
+		m.token = Token{}
+		m.refreshAt = time.Time{}
+	}
+}
+
+// run refreshes the token in the background until ctx is cancelled by Close.
+func (m *tokenManager) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		wait := m.refresher.retryInterval()
+		notDue := func(now time.Time) bool { return !m.due(now) }
+		if _, err := m.refresh(ctx, notDue); err == nil {
+			wait = m.untilDue()
+		}
+
+		timer.Reset(wait)
+	}
+}
+
+// untilDue returns the time left until the current token is due, or a day
+// for tokens that never expire. It waits at least RetryInterval, so tokens
+// that arrive already expired do not make run spin.
+func (m *tokenManager) untilDue() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.refreshAt.IsZero() {
+		return 24 * time.Hour
+	}
+	return max(time.Until(m.refreshAt), m.refresher.retryInterval())
+}
@@ -0,0 +1,157 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenRefresherRefreshesBeforeExpiry(t *testing.T) {
+	const lifetime = 200 * time.Millisecond
+
+	var (
+		mu       sync.Mutex
+		fetches  []time.Time
+		expiries []time.Time
+	)
+	refresher := &TokenRefresher{
+		Fetch: func(ctx context.Context) (Token, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			now := time.Now()
+			fetches = append(fetches, now)
+			expiries = append(expiries, now.Add(lifetime))
+			return Token{AccessToken: fmt.Sprint("token-", len(fetches)), Expiry: now.Add(lifetime)}, nil
+		},
+		Margin:        50 * time.Millisecond,
+		Jitter:        -1,
+		RetryInterval: 10 * time.Millisecond,
+	}
+
+	c := New(&Config{TokenRefresher: refresher})
+	time.Sleep(4 * lifetime)
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if tasks := c.Tasks(); len(tasks) != 0 {
+		t.Fatalf("tasks after Close = %v, want none", tasks)
+	}
+
+	mu.Lock()
+	closed := len(fetches)
+	for i := 1; i < len(fetches); i++ {
+		if !fetches[i].Before(expiries[i-1]) {
+			t.Errorf("fetch %d at %v, after token %d expired at %v", i+1, fetches[i], i, expiries[i-1])
+		}
+	}
+	mu.Unlock()
+
+	// One fetch up front, then one every lifetime minus margin
+	if closed < 3 || closed > 8 {
+		t.Errorf("fetches = %d, want about 5", closed)
+	}
+
+	time.Sleep(2 * lifetime)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(fetches) != closed {
+		t.Errorf("fetches after Close = %d, want %d", len(fetches), closed)
+	}
+}
+
+func TestTokenRefresherShortLivedToken(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetches int
+	)
+	refresher := &TokenRefresher{
+		Fetch: func(ctx context.Context) (Token, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			fetches++
+			return Token{AccessToken: "token", Expiry: time.Now().Add(100 * time.Millisecond)}, nil
+		},
+		RetryInterval: 10 * time.Millisecond,
+	}
+
+	// The default margin alone exceeds the lifetime of the token
+	c := New(&Config{TokenRefresher: refresher})
+	time.Sleep(300 * time.Millisecond)
+	c.Close(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches < 2 || fetches > 10 {
+		t.Errorf("fetches = %d, want one every 50ms", fetches)
+	}
+}
+
+func TestTokenRefresherAuthorizesRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	c := New(&Config{TokenRefresher: &TokenRefresher{
+		Fetch: func(ctx context.Context) (Token, error) {
+			return Token{AccessToken: "abc", TokenType: "MAC"}, nil
+		},
+	}})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Authorization"); got != "MAC abc" {
+		t.Errorf("Authorization = %q, want %q", got, "MAC abc")
+	}
+}
+
+func TestTokenRefresherRequiresFetch(t *testing.T) {
+	if _, err := NewClient(&Config{TokenRefresher: &TokenRefresher{Margin: time.Minute}}); err == nil {
+		t.Fatal("NewClient accepted a TokenRefresher without Fetch")
+	}
+
+	// New does not panic either; its requests report the error
+	c := New(&Config{TokenRefresher: &TokenRefresher{}})
+	defer c.Close(context.Background())
+
+	if _, err := c.Get("http://127.0.0.1:1"); err == nil || !strings.Contains(err.Error(), "TokenRefresher.Fetch") {
+		t.Errorf("err = %v, want the missing Fetch reported", err)
+	}
+}
+
+func TestTokenManagerRefreshTime(t *testing.T) {
+	now := time.Now()
+	m := newTokenManager(&TokenRefresher{Margin: time.Minute, Jitter: -1})
+
+	tests := []struct {
+		name     string
+		lifetime time.Duration
+		want     time.Duration
+	}{
+		{"long-lived", time.Hour, time.Hour - time.Minute},
+		{"shorter than margin", 10 * time.Second, 5 * time.Second},
+		{"equal to margin", time.Minute, 30 * time.Second},
+		{"expired", -time.Second, -500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.refreshTime(now, now.Add(tt.lifetime)).Sub(now)
+			if got != tt.want {
+				t.Errorf("refresh after %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
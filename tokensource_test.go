@@ -0,0 +1,216 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenEndpoint serves client credentials tokens numbered by issue order.
+type tokenEndpoint struct {
+	expiresIn int64
+	issued    atomic.Int64
+	auth      atomic.Value // last Authorization header
+}
+
+func (e *tokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.auth.Store(r.Header.Get("Authorization"))
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		http.Error(w, "bad grant", http.StatusBadRequest)
+		return
+	}
+
+	n := e.issued.Add(1)
+	time.Sleep(10 * time.Millisecond)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": fmt.Sprint("token-", n),
+		"token_type":   "Bearer",
+		"expires_in":   e.expiresIn,
+	})
+}
+
+func TestClientCredentialsSourceSingleFlight(t *testing.T) {
+	endpoint := &tokenEndpoint{expiresIn: 3600}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	source := ClientCredentialsSource(auth.URL, "id", "secret", []string{"read"})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := source.Token(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := endpoint.issued.Load(); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestClientCredentialsSourceShortLivedToken(t *testing.T) {
+	// One second is shorter than the default refresh margin
+	endpoint := &tokenEndpoint{expiresIn: 1}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	source := ClientCredentialsSource(auth.URL, "id", "secret", nil)
+	for range 10 {
+		if _, err := source.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := endpoint.issued.Load(); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestClientCredentialsSourceEncodesCredentials(t *testing.T) {
+	endpoint := &tokenEndpoint{expiresIn: 3600}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	source := ClientCredentialsSource(auth.URL, "my client", "p@ss:word", nil)
+	if _, err := source.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &http.Request{Header: http.Header{"Authorization": {endpoint.auth.Load().(string)}}}
+	user, pass, ok := req.BasicAuth()
+	if !ok {
+		t.Fatal("no basic credentials sent")
+	}
+	if user != "my+client" || pass != "p%40ss%3Aword" {
+		t.Errorf("credentials = %q:%q, want form-urlencoded", user, pass)
+	}
+	if id, _ := url.QueryUnescape(user); id != "my client" {
+		t.Errorf("client ID = %q", id)
+	}
+}
+
+func TestTokenSourceRetriesUnauthorizedOnce(t *testing.T) {
+	endpoint := &tokenEndpoint{expiresIn: 3600}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	var calls atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer api.Close()
+
+	c := New(&Config{TokenSource: ClientCredentialsSource(auth.URL, "id", "secret", nil)})
+	defer c.Close(context.Background())
+
+	res, err := c.Post(api.URL, WithBody("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server called %d times, want 2", n)
+	}
+	if n := endpoint.issued.Load(); n != 2 {
+		t.Errorf("tokens issued = %d, want 2", n)
+	}
+}
+
+func TestTokenSourceDrainIsBounded(t *testing.T) {
+	endpoint := &tokenEndpoint{expiresIn: 3600}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-2" {
+			return
+		}
+
+		// The rejection streams an endless body
+		w.WriteHeader(http.StatusUnauthorized)
+		chunk := make([]byte, 32<<10)
+		for r.Context().Err() == nil {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer api.Close()
+
+	c := New(&Config{TokenSource: ClientCredentialsSource(auth.URL, "id", "secret", nil)})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(api.URL, WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("retry stuck draining the rejected attempt: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+}
+
+// signerFunc adapts a function to the Signer interface.
+type signerFunc func(req *http.Request, bodyHash string) error
+
+func (f signerFunc) Sign(req *http.Request, bodyHash string) error { return f(req, bodyHash) }
+
+func TestTokenSourceKeepsSignerAuthorization(t *testing.T) {
+	endpoint := &tokenEndpoint{expiresIn: 3600}
+	auth := httptest.NewServer(endpoint)
+	defer auth.Close()
+
+	var calls atomic.Int64
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+
+	c := New(&Config{
+		TokenSource: ClientCredentialsSource(auth.URL, "id", "secret", nil),
+		Signer: signerFunc(func(req *http.Request, _ string) error {
+			req.Header.Set("Authorization", "Signature abc")
+			return nil
+		}),
+	})
+	defer c.Close(context.Background())
+
+	res, err := c.Get(api.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Authorization"); got != "Signature abc" {
+		t.Errorf("Authorization = %q, want the signature", got)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,212 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// TrafficStats is a snapshot of the bytes a client has exchanged since it
+// was created, e.g. for egress cost tracking.
+//
+// Sizes are wire bytes: request bodies after compression, compressed
+// response bodies before decoding, plus request and status lines and
+// headers. Header sizes are those of the HTTP/1.1 encoding, so they are an
+// upper bound for HTTP/2 with its header compression. TLS overhead is not
+// included.
+type TrafficStats struct {
+	BytesSent     int64                  // Bytes written, including headers
+	BytesReceived int64                  // Bytes read, including headers
+	Hosts         map[string]HostTraffic // Per request host (host[:port])
+}
+
+// HostTraffic is the share of TrafficStats for a single host.
+type HostTraffic struct {
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// trafficCounter accumulates TrafficStats with atomics so that it can stay
+// enabled on every request.
+type trafficCounter struct {
+	sent     atomic.Int64
+	received atomic.Int64
+	hosts    sync.Map // host -> *hostCounter
+	metrics  Metrics  // also receives every count when set
+}
+
+// hostCounter holds the counters of a single host.
+type hostCounter struct {
+	name     string
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// host returns the counter for host, creating it on first use.
+func (t *trafficCounter) host(host string) *hostCounter {
+	if h, ok := t.hosts.Load(host); ok {
+		return h.(*hostCounter)
+	}
+	h, _ := t.hosts.LoadOrStore(host, &hostCounter{name: host})
+	return h.(*hostCounter)
+}
+
+// addSent records n bytes written to h.
+func (t *trafficCounter) addSent(h *hostCounter, n int64) {
+	t.sent.Add(n)
+	h.sent.Add(n)
+	if t.metrics != nil {
+		t.metrics.AddTraffic(h.name, n, 0)
+	}
+}
+
+// addReceived records n bytes read from h.
+func (t *trafficCounter) addReceived(h *hostCounter, n int64) {
+	t.received.Add(n)
+	h.received.Add(n)
+	if t.metrics != nil {
+		t.metrics.AddTraffic(h.name, 0, n)
+	}
+}
+
+// snapshot returns the current counters.
+func (t *trafficCounter) snapshot() TrafficStats {
+	stats := TrafficStats{
+		BytesSent:     t.sent.Load(),
+		BytesReceived: t.received.Load(),
+		Hosts:         make(map[string]HostTraffic),
+	}
+
+	t.hosts.Range(func(key, value any) bool {
+		h := value.(*hostCounter)
+		stats.Hosts[key.(string)] = HostTraffic{BytesSent: h.sent.Load(), BytesReceived: h.received.Load()}
+		return true
+	})
+
+	return stats
+}
+
+// Traffic returns the bytes sent and received by the client so far.
+func (c *client) Traffic() TrafficStats {
+	return c.traffic.snapshot()
+}
+
+// trafficTransport counts the bytes of every round trip, including retries
+// and redirects, before passing it to base.
+//
+// The standard transport decodes gzip responses it asked for itself, which
+// would hide the wire size of the body. When httpx built the transport,
+// compression is therefore disabled there and done here the same way, after
+// counting.
+type trafficTransport struct {
+	base       http.RoundTripper
+	counter    *trafficCounter
+	decompress bool // ask for and decode gzip on behalf of base
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *trafficTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := t.counter.host(req.URL.Host)
+
+	// Request line and headers as written by the transport
+	t.counter.addSent(h, int64(len(req.Method)+len(req.URL.RequestURI())+len(" HTTP/1.1\r\n")+1))
+	trace := &httptrace.ClientTrace{
+		WroteHeaderField: func(key string, values []string) {
+			for _, v := range values {
+				t.counter.addSent(h, int64(len(key)+len(v)+len(": \r\n")))
+			}
+		},
+		WroteHeaders: func() {
+			t.counter.addSent(h, int64(len("\r\n")))
+		},
+	}
+	counted := req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	if req.Body != nil && req.Body != http.NoBody {
+		counted.Body = &countingBody{ReadCloser: req.Body, add: func(n int64) { t.counter.addSent(h, n) }}
+		if req.GetBody != nil {
+			counted.GetBody = func() (io.ReadCloser, error) {
+				body, err := req.GetBody()
+				if err != nil || body == http.NoBody {
+					return body, err
+				}
+				return &countingBody{ReadCloser: body, add: func(n int64) { t.counter.addSent(h, n) }}, nil
+			}
+		}
+	}
+
+	requestedGzip := false
+	if t.decompress && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		requestedGzip = true
+		counted.Header = req.Header.Clone()
+		counted.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	res, err := t.base.RoundTrip(counted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Status line and headers
+	received := len(res.Proto) + len(" ") + len(res.Status) + len("\r\n\r\n")
+	for key, values := range res.Header {
+		for _, v := range values {
+			received += len(key) + len(v) + len(": \r\n")
+		}
+	}
+	t.counter.addReceived(h, int64(received))
+
+	if res.Body != nil && res.Body != http.NoBody {
+		res.Body = &countingBody{ReadCloser: res.Body, add: func(n int64) { t.counter.addReceived(h, n) }}
+	}
+
+	if requestedGzip && strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		res.Body = &gzipBody{body: res.Body}
+		res.Header.Del("Content-Encoding")
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Uncompressed = true
+	}
+
+	return res, nil
+}
+
+// countingBody reports the bytes read through it.
+type countingBody struct {
+	io.ReadCloser
+	add func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.add(int64(n))
+	}
+	return n, err
+}
+
+// gzipBody decodes a gzip response body lazily on first read, so that an
+// invalid stream surfaces as a read error rather than a request error.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
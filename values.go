@@ -0,0 +1,303 @@
+package httpx
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// paramEncoders holds custom value encoders registered through
+// RegisterParamEncoder, keyed by the exact Go type they handle.
+var (
+	paramEncodersMu sync.RWMutex
+	paramEncoders   = map[reflect.Type]func(reflect.Value) (string, error){}
+)
+
+// RegisterParamEncoder registers a custom encoder used whenever a value of
+// type T appears in a struct encoded as query parameters or form fields.
+// Registered encoders take precedence over the built-in rules, including
+// encoding.TextMarshaler. Registering the same type twice replaces the
+// previous encoder.
+//
+// Example:
+//
+//	httpx.RegisterParamEncoder(func(m Money) (string, error) {
+//	    return fmt.Sprintf("%.2f %s", m.Amount, m.Currency), nil
+//	})
+func RegisterParamEncoder[T any](fn func(T) (string, error)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	paramEncodersMu.Lock()
+	defer paramEncodersMu.Unlock()
+
+	paramEncoders[t] = func(v reflect.Value) (string, error) {
+		return fn(v.Interface().(T))
+	}
+}
+
+// lookupParamEncoder returns the registered encoder for t, if any.
+func lookupParamEncoder(t reflect.Type) (func(reflect.Value) (string, error), bool) {
+	paramEncodersMu.RLock()
+	defer paramEncodersMu.RUnlock()
+
+	fn, ok := paramEncoders[t]
+	return fn, ok
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// paramFormat holds per-request formatting choices for encoded parameters.
+type paramFormat struct {
+	boolAsInt bool // encode booleans as 1/0 instead of true/false
+}
+
+// encodeValues converts v into url.Values. It is the shared encoder behind
+// struct-based query parameters and form bodies.
+//
+// Accepted inputs are url.Values, map[string]string, and structs (or pointers
+// to structs). Struct fields are controlled by the `url` tag:
+//
+//	type Filter struct {
+//	    Name    string        `url:"name"`
+//	    Since   time.Time     `url:"since,omitempty" layout:"2006-01-02"`
+//	    Timeout time.Duration `url:"timeout,seconds"`
+//	    Tags    []string      `url:"tag"`
+//	    Secret  string        `url:"-"`
+//	}
+//
+// Encoding rules, in order of precedence:
+//   - types registered with RegisterParamEncoder use their encoder
+//   - time.Time uses the `layout` tag, defaulting to time.RFC3339
+//   - time.Duration uses Go duration syntax ("1m30s"), or seconds with the
+//     "seconds" tag option
+//   - types implementing encoding.TextMarshaler use MarshalText
+//   - strings, booleans, integers and floats use their usual representation;
+//     booleans become 1/0 when format.boolAsInt is set
+//   - slices and arrays produce one value per element under the same key
+//
+// Nil pointers are skipped, zero values are skipped under "omitempty", and
+// embedded structs are flattened. Any other type returns an error naming the
+// field path.
+//
+// The encoded form is stable: url.Values.Encode sorts by key and keeps the
+// order of values per key, so equal input always produces the same bytes.
+func encodeValues(v any, format paramFormat) (url.Values, error) {
+	switch cast := v.(type) {
+	case nil:
+		return url.Values{}, nil
+	case url.Values:
+		return cast, nil
+	case map[string]string:
+		values := url.Values{}
+		for k, val := range cast {
+			values.Set(k, val)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("httpx: cannot encode %T as parameters, expected struct, map[string]string or url.Values", v)
+	}
+
+	values := url.Values{}
+	if err := encodeStruct(values, rv, "", format); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// encodeStruct appends all encodable fields of rv to values. The path is the
+// dotted Go field path of rv, used for error messages.
+func encodeStruct(values url.Values, rv reflect.Value, path string, format paramFormat) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := hasTagOption(opts, "omitempty")
+
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		fv := rv.Field(i)
+
+		// Skip nil pointers, dereference the rest
+		for fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Pointer {
+			continue
+		}
+
+		// Flatten untagged embedded structs
+		if field.Anonymous && name == "" && fv.Kind() == reflect.Struct && !hasScalarEncoding(fv.Type()) {
+			if err := encodeStruct(values, fv, fieldPath, format); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && !hasScalarEncoding(fv.Type()) {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := encodeScalar(fv.Index(j), field.Tag, opts, fmt.Sprintf("%s[%d]", fieldPath, j), format)
+				if err != nil {
+					return err
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		s, err := encodeScalar(fv, field.Tag, opts, fieldPath, format)
+		if err != nil {
+			return err
+		}
+		values.Add(name, s)
+	}
+
+	return nil
+}
+
+// hasScalarEncoding reports whether t is encoded as a single value even
+// though its kind is a struct, slice or array (e.g. time.Time, net.IP).
+func hasScalarEncoding(t reflect.Type) bool {
+	if _, ok := lookupParamEncoder(t); ok {
+		return true
+	}
+	if t == timeType {
+		return true
+	}
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// encodeScalar converts a single value into its parameter representation.
+func encodeScalar(fv reflect.Value, tag reflect.StructTag, opts, path string, format paramFormat) (string, error) {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return "", nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fn, ok := lookupParamEncoder(fv.Type()); ok {
+		s, err := fn(fv)
+		if err != nil {
+			return "", fmt.Errorf("httpx: encoding field %s: %w", path, err)
+		}
+		return s, nil
+	}
+
+	switch fv.Type() {
+	case timeType:
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return fv.Interface().(time.Time).Format(layout), nil
+
+	case durationType:
+		d := fv.Interface().(time.Duration)
+		if hasTagOption(opts, "seconds") {
+			return strconv.FormatFloat(d.Seconds(), 'f', -1, 64), nil
+		}
+		return d.String(), nil
+	}
+
+	if m, ok := textMarshaler(fv); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("httpx: encoding field %s: %w", path, err)
+		}
+		return string(b), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		if format.boolAsInt {
+			if fv.Bool() {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("httpx: unsupported parameter type %s for field %s", fv.Type(), path)
+}
+
+// textMarshaler returns fv as an encoding.TextMarshaler, taking the address
+// when only the pointer type implements the interface.
+func textMarshaler(fv reflect.Value) (encoding.TextMarshaler, bool) {
+	if fv.Type().Implements(textMarshalerType) {
+		return fv.Interface().(encoding.TextMarshaler), true
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(textMarshalerType) {
+		return fv.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	if reflect.PointerTo(fv.Type()).Implements(textMarshalerType) {
+		ptr := reflect.New(fv.Type())
+		ptr.Elem().Set(fv)
+		return ptr.Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+// hasTagOption reports whether the comma-separated tag options contain opt.
+func hasTagOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
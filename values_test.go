@@ -0,0 +1,234 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// money has a registered parameter encoder.
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+// level implements encoding.TextMarshaler on the pointer receiver only.
+type level int
+
+func (l *level) MarshalText() ([]byte, error) {
+	if *l < 0 {
+		return nil, errors.New("negative level")
+	}
+	return []byte(fmt.Sprintf("L%d", int(*l))), nil
+}
+
+func init() {
+	RegisterParamEncoder(func(m money) (string, error) {
+		if m.Currency == "" {
+			return "", errors.New("missing currency")
+		}
+		return fmt.Sprintf("%d.%02d %s", m.Cents/100, m.Cents%100, m.Currency), nil
+	})
+}
+
+func TestEncodeValues(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	name := "John"
+	lvl := level(3)
+
+	type Embedded struct {
+		Page int `url:"page"`
+	}
+
+	tests := []struct {
+		name    string
+		in      any
+		format  paramFormat
+		want    string // url.Values.Encode of the result
+		wantErr string // substring of the error
+	}{
+		{name: "nil", in: nil, want: ""},
+		{name: "url.Values", in: url.Values{"a": {"1", "2"}}, want: "a=1&a=2"},
+		{name: "map", in: map[string]string{"b": "2", "a": "1"}, want: "a=1&b=2"},
+		{name: "nil struct pointer", in: (*struct{ A string })(nil), want: ""},
+		{name: "not a struct", in: 42, wantErr: "cannot encode int"},
+
+		{name: "field name without tag", in: struct{ Name string }{"x"}, want: "Name=x"},
+		{name: "tag name", in: struct {
+			Name string `url:"n"`
+		}{"x"}, want: "n=x"},
+		{name: "skipped field", in: struct {
+			Secret string `url:"-"`
+		}{"x"}, want: ""},
+		{name: "unexported field", in: struct{ name string }{"x"}, want: ""},
+
+		{name: "scalars", in: struct {
+			S   string  `url:"s"`
+			B   bool    `url:"b"`
+			I   int     `url:"i"`
+			I8  int8    `url:"i8"`
+			U   uint64  `url:"u"`
+			F32 float32 `url:"f32"`
+			F64 float64 `url:"f64"`
+		}{"a b", true, -1, 8, 18446744073709551615, 1.5, 0.1}, want: "b=true&f32=1.5&f64=0.1&i=-1&i8=8&s=a+b&u=18446744073709551615"},
+		{name: "bool as int", in: struct {
+			T bool `url:"t"`
+			F bool `url:"f"`
+		}{true, false}, format: paramFormat{boolAsInt: true}, want: "f=0&t=1"},
+
+		{name: "time default layout", in: struct {
+			Since time.Time `url:"since"`
+		}{since}, want: "since=2024-03-01T12%3A30%3A00Z"},
+		{name: "time custom layout", in: struct {
+			Since time.Time `url:"since" layout:"2006-01-02"`
+		}{since}, want: "since=2024-03-01"},
+		{name: "zero time omitted", in: struct {
+			Since time.Time `url:"since,omitempty"`
+		}{}, want: ""},
+		{name: "zero time kept", in: struct {
+			Since time.Time `url:"since" layout:"2006"`
+		}{}, want: "since=0001"},
+
+		{name: "duration", in: struct {
+			D time.Duration `url:"d"`
+		}{90 * time.Second}, want: "d=1m30s"},
+		{name: "duration in seconds", in: struct {
+			D time.Duration `url:"d,seconds"`
+		}{1500 * time.Millisecond}, want: "d=1.5"},
+		{name: "duration in seconds, omitempty", in: struct {
+			D time.Duration `url:"d,omitempty,seconds"`
+		}{}, want: ""},
+
+		{name: "text marshaler", in: struct {
+			IP net.IP `url:"ip"`
+		}{net.IPv4(10, 0, 0, 1)}, want: "ip=10.0.0.1"},
+		{name: "pointer receiver text marshaler", in: struct {
+			L level `url:"l"`
+		}{3}, want: "l=L3"},
+		{name: "text marshaler error", in: struct {
+			L level `url:"l"`
+		}{-1}, wantErr: "field L: negative level"},
+
+		{name: "registered encoder", in: struct {
+			Price money `url:"price"`
+		}{money{1234, "EUR"}}, want: "price=12.34+EUR"},
+		{name: "registered encoder error", in: struct {
+			Price money `url:"price"`
+		}{money{Cents: 1}}, wantErr: "field Price: missing currency"},
+		{name: "registered encoder in slice", in: struct {
+			Prices []money `url:"p"`
+		}{[]money{{100, "EUR"}, {250, "USD"}}}, want: "p=1.00+EUR&p=2.50+USD"},
+
+		{name: "nil pointer skipped", in: struct {
+			Name *string `url:"name"`
+		}{}, want: ""},
+		{name: "pointer dereferenced", in: struct {
+			Name *string `url:"name"`
+			L    *level  `url:"l"`
+		}{&name, &lvl}, want: "l=L3&name=John"},
+		{name: "zero omitted", in: struct {
+			N int    `url:"n,omitempty"`
+			S string `url:"s,omitempty"`
+		}{}, want: ""},
+		{name: "zero kept", in: struct {
+			N int `url:"n"`
+		}{}, want: "n=0"},
+
+		{name: "slice", in: struct {
+			Tags []string `url:"tag"`
+		}{[]string{"b", "a"}}, want: "tag=b&tag=a"},
+		{name: "array", in: struct {
+			Pair [2]int `url:"p"`
+		}{[2]int{1, 2}}, want: "p=1&p=2"},
+		{name: "empty slice omitted", in: struct {
+			Tags []string `url:"tag,omitempty"`
+		}{}, want: ""},
+
+		{name: "embedded struct flattened", in: struct {
+			Embedded
+			Q string `url:"q"`
+		}{Embedded{2}, "go"}, want: "page=2&q=go"},
+
+		{name: "unsupported type", in: struct {
+			M map[string]int `url:"m"`
+		}{map[string]int{"a": 1}}, wantErr: "unsupported parameter type map[string]int for field M"},
+		{name: "unsupported struct field", in: struct {
+			Inner struct {
+				C chan int
+			} `url:"inner"`
+		}{}, wantErr: "for field Inner"},
+		{name: "unsupported embedded field path", in: struct {
+			Embedded
+			Inner struct{ Embedded } `url:"inner"`
+		}{}, wantErr: "for field Inner"},
+		{name: "unsupported slice element path", in: struct {
+			Vs []any `url:"v"`
+		}{[]any{"ok", make(chan int)}}, wantErr: "chan int for field Vs[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := encodeValues(tt.in, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values.Encode(); got != tt.want {
+				t.Errorf("encoded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryAndFormShareEncoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Form", r.PostForm.Encode())
+	}))
+	defer srv.Close()
+
+	type Params struct {
+		Since time.Time     `url:"since" layout:"2006-01-02"`
+		Wait  time.Duration `url:"wait,seconds"`
+		Price money         `url:"price"`
+	}
+	params := Params{Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Wait: 2 * time.Second, Price: money{500, "EUR"}}
+	want := "price=5.00+EUR&since=2024-03-01&wait=2"
+
+	c := New(nil)
+	defer c.Close(context.Background())
+
+	res, err := c.Post(srv.URL,
+		WithQuery(params),
+		WithBody(params),
+		WithHeaders(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got := res.Header.Get("X-Query"); got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+	if got := res.Header.Get("X-Form"); got != want {
+		t.Errorf("form = %q, want %q", got, want)
+	}
+
+	// Encoding errors fail the request before it is sent
+	if _, err := c.Get(srv.URL, WithQuery(struct{ C chan int }{})); err == nil || !strings.Contains(err.Error(), "field C") {
+		t.Errorf("err = %v, want an unsupported type error", err)
+	}
+}